@@ -0,0 +1,18 @@
+//go:build !unix
+
+package main
+
+import (
+	"os"
+)
+
+func checkWritable(path string) error {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if stat.Mode().Perm()&0o200 == 0 {
+		return os.ErrPermission
+	}
+	return nil
+}
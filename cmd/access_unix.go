@@ -0,0 +1,11 @@
+//go:build unix
+
+package main
+
+import "syscall"
+
+const accessWrite = 0x2
+
+func checkWritable(path string) error {
+	return syscall.Access(path, accessWrite)
+}
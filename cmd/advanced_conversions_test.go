@@ -8,6 +8,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -21,7 +23,7 @@ func TestAdvancedConversions(t *testing.T) {
 	}()
 
 	t.Run("ok with stdin input and stdout result, conv and block-size options", func(t *testing.T) {
-		cmd = exec.Command(binPath, "-conv", "trim_spaces,upper_case", "-block-size", "1")
+		cmd = exec.Command(binPath, "-quiet", "-conv", "trim_spaces,upper_case", "-block-size", "1")
 		cmd.Stdin = strings.NewReader(testInput)
 		stdout := &strings.Builder{}
 		cmd.Stdout = stdout
@@ -39,7 +41,7 @@ func TestAdvancedConversions(t *testing.T) {
 		limit := 9999
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
 		defer cancel()
-		cmd = exec.CommandContext(ctx, binPath, "-limit", strconv.Itoa(limit), "-block-size", "1024", "-conv", "trim_spaces")
+		cmd = exec.CommandContext(ctx, binPath, "-quiet", "-limit", strconv.Itoa(limit), "-block-size", "1024", "-conv", "trim_spaces")
 
 		cmd.Stdin = &unlimitedReader{input: []byte(" ")}
 		stdout := &strings.Builder{}
@@ -59,7 +61,7 @@ func TestAdvancedConversions(t *testing.T) {
 		limit := 10000
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
 		defer cancel()
-		cmd = exec.CommandContext(ctx, binPath, "-limit", strconv.Itoa(limit), "-block-size", "1024", "-conv", "trim_spaces")
+		cmd = exec.CommandContext(ctx, binPath, "-quiet", "-limit", strconv.Itoa(limit), "-block-size", "1024", "-conv", "trim_spaces")
 
 		cmd.Stdin = &unlimitedReader{input: []byte(" "), prefix: []byte(" ")}
 		stdout := &strings.Builder{}
@@ -74,4 +76,304 @@ func TestAdvancedConversions(t *testing.T) {
 		assert.Zero(t, stderr.Len(), stderr.String())
 		assert.Equal(t, "", stdout.String())
 	})
+
+	t.Run("ok, trim_left keeps trailing spaces", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "trim_left", "-block-size", "3")
+		cmd.Stdin = strings.NewReader(testInput)
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, strings.TrimLeftFunc(testInput, unicode.IsSpace), stdout.String())
+	})
+
+	t.Run("ok, trim_right keeps leading spaces", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "trim_right", "-block-size", "3")
+		cmd.Stdin = strings.NewReader(testInput)
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, strings.TrimRightFunc(testInput, unicode.IsSpace), stdout.String())
+	})
+
+	t.Run("ok, trim_left and trim_right together equal trim_spaces", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "trim_left,trim_right", "-block-size", "1")
+		cmd.Stdin = strings.NewReader(testInput)
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, strings.TrimSpace(testInput), stdout.String())
+	})
+
+	t.Run("error, trim_spaces together with trim_left", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "trim_spaces,trim_left")
+		cmd.Stdin = strings.NewReader(testInput)
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.NotZero(t, stderr.Len())
+		assert.Zero(t, stdout.Len())
+	})
+
+	t.Run("ok, squeeze_spaces with multi-byte spaces split between blocks", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "squeeze_spaces", "-block-size", "2")
+		cmd.Stdin = strings.NewReader("  a\u3000\u3000 b\n\n\tв\u00a0г ")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, " a b в г ", stdout.String())
+	})
+
+	t.Run("ok, squeeze_spaces with trim_spaces", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "trim_spaces,squeeze_spaces", "-block-size", "1")
+		cmd.Stdin = strings.NewReader(testInput)
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, strings.Join(strings.Fields(testInput), " "), stdout.String())
+	})
+
+	t.Run("ok, nfc with combining mark split at block boundary", func(t *testing.T) {
+		for _, blockSize := range []string{"1", "3", "1024"} {
+			cmd = exec.Command(binPath, "-quiet", "-conv", "nfc", "-block-size", blockSize)
+			cmd.Stdin = strings.NewReader("abe\u0301 cafe\u0301")
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.NoError(t, err)
+			assert.Zero(t, stderr.Len(), stderr.String())
+			assert.Equal(t, "ab\u00e9 caf\u00e9", stdout.String())
+		}
+	})
+
+	t.Run("ok, nfd with block-size 1", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "nfd", "-block-size", "1")
+		cmd.Stdin = strings.NewReader("caf\u00e9 \u00c5ngstr\u00f6m")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, "cafe\u0301 A\u030angstro\u0308m", stdout.String())
+	})
+
+	t.Run("error, nfc together with nfd", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "nfc,nfd")
+		cmd.Stdin = strings.NewReader(testInput)
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.NotZero(t, stderr.Len())
+		assert.Zero(t, stdout.Len())
+	})
+
+	t.Run("ok, ascii_fold with mixed scripts", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "ascii_fold", "-block-size", "1")
+		cmd.Stdin = strings.NewReader("Crème brûlée, Straße, Øresund, Привет, cafe\u0301 😊")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, "Creme brulee, Strasse, Oresund, Привет, cafe 😊", stdout.String())
+	})
+
+	t.Run("ok, ascii_fold with replacement of unknown runes", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "ascii_fold", "-ascii-fold-replace")
+		cmd.Stdin = strings.NewReader("Ünïcödé Мир")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, "Unicode ???", stdout.String())
+	})
+
+	t.Run("ok, strip_bom with BOM split between blocks", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "strip_bom", "-block-size", "1")
+		cmd.Stdin = strings.NewReader("\ufeffdata\ufeff")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, "data\ufeff", stdout.String())
+	})
+
+	t.Run("ok, strip_bom and add_bom keep exactly one BOM", func(t *testing.T) {
+		for _, input := range []string{"\ufeffdata", "data", ""} {
+			cmd = exec.Command(binPath, "-quiet", "-conv", "strip_bom,add_bom", "-block-size", "2")
+			cmd.Stdin = strings.NewReader(input)
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.NoError(t, err)
+			assert.Zero(t, stderr.Len(), stderr.String())
+			assert.Equal(t, "\ufeff"+strings.TrimPrefix(input, "\ufeff"), stdout.String())
+		}
+	})
+
+	t.Run("error, strip_bom with UTF-16 BOM", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "strip_bom")
+		cmd.Stdin = strings.NewReader("\xff\xfeh\x00i\x00")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "UTF-16")
+		assert.Zero(t, stdout.Len())
+	})
+
+	t.Run("ok, strip_control keeps multi-byte runes", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "strip_control", "-block-size", "1")
+		cmd.Stdin = strings.NewReader("\x1b[31mкрасный\x1b[0m\x00\ta\r\n\xffб\u0085")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, "[31mкрасный[0m\ta\r\nб", stdout.String())
+	})
+
+	t.Run("ok, strip_control with control characters only", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "strip_control")
+		cmd.Stdin = strings.NewReader(strings.Repeat("\x00\x01\x07\x1b\x7f", 1000))
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Zero(t, stdout.Len())
+	})
+
+	t.Run("ok, fix_utf8 keeps runes split between blocks", func(t *testing.T) {
+		for _, blockSize := range []string{"1", "2", "3", "1024"} {
+			cmd = exec.Command(binPath, "-quiet", "-conv", "fix_utf8", "-block-size", blockSize)
+			cmd.Stdin = strings.NewReader(testInput + "\xff😊\xe2\x82x\xf0\x9f\x98")
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.NoError(t, err)
+			assert.Zero(t, stderr.Len(), stderr.String())
+			assert.Equal(t, testInput+"\ufffd😊\ufffd\ufffdx\ufffd\ufffd\ufffd", stdout.String())
+			assert.True(t, utf8.ValidString(stdout.String()))
+		}
+	})
+
+	t.Run("ok, map with runes of different byte lengths", func(t *testing.T) {
+		for _, blockSize := range []string{"1", "2", "3", "1024"} {
+			cmd = exec.Command(binPath, "-quiet", "-conv", "map", "-map", "абвa😊:abvж!", "-block-size", blockSize)
+			cmd.Stdin = strings.NewReader("вбаaa😊 где\xff")
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.NoError(t, err)
+			assert.Zero(t, stderr.Len(), stderr.String())
+			assert.Equal(t, "vbaжж! где\xff", stdout.String())
+		}
+	})
+
+	t.Run("fail, map with invalid -map", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"-conv", "map"},
+			{"-conv", "map", "-map", "абв:ab"},
+			{"-conv", "map", "-map", "abc"},
+		} {
+			cmd = exec.Command(binPath, args...)
+			cmd.Stdin = strings.NewReader("abc")
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.Error(t, err)
+			assert.Zero(t, stdout.Len())
+			assert.Contains(t, stderr.String(), "-map")
+		}
+	})
 }
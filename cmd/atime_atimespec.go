@@ -0,0 +1,16 @@
+//go:build darwin || freebsd || netbsd
+
+package main
+
+import (
+	"io/fs"
+	"syscall"
+	"time"
+)
+
+func accessTime(stat fs.FileInfo) time.Time {
+	if sys, ok := stat.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(sys.Atimespec.Unix())
+	}
+	return stat.ModTime()
+}
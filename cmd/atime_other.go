@@ -0,0 +1,12 @@
+//go:build !(linux || openbsd || dragonfly || darwin || freebsd || netbsd)
+
+package main
+
+import (
+	"io/fs"
+	"time"
+)
+
+func accessTime(stat fs.FileInfo) time.Time {
+	return stat.ModTime()
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+type atomicWriter struct {
+	file    *os.File
+	target  string
+	syncDir bool
+}
+
+func createAtomic(target string, mode os.FileMode, syncDir bool) (*atomicWriter, error) {
+	file, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".tmp")
+	if err != nil {
+		return nil, err
+	}
+	if err = file.Chmod(mode); err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return nil, err
+	}
+	diag.verbosef("writing to temporary file %s", file.Name())
+	return &atomicWriter{file: file, target: target, syncDir: syncDir}, nil
+}
+
+func (aw *atomicWriter) Write(p []byte) (int, error) {
+	return aw.file.Write(p)
+}
+
+func (aw *atomicWriter) Close() error {
+	if err := aw.file.Sync(); err != nil {
+		aw.abort()
+		return err
+	}
+	if err := aw.file.Close(); err != nil {
+		_ = os.Remove(aw.file.Name())
+		return err
+	}
+	if err := os.Rename(aw.file.Name(), aw.target); err != nil {
+		_ = os.Remove(aw.file.Name())
+		return fmt.Errorf("can not rename %s over %s, -atomic needs both on the same filesystem: %w", aw.file.Name(), aw.target, err)
+	}
+	diag.verbosef("renamed %s to %s", aw.file.Name(), aw.target)
+	if aw.syncDir {
+		return syncDir(filepath.Dir(aw.target))
+	}
+	return nil
+}
+
+func (aw *atomicWriter) abort() {
+	_ = aw.file.Close()
+	_ = os.Remove(aw.file.Name())
+	diag.verbosef("removed temporary file %s", aw.file.Name())
+}
+
+func abortWriter(writer io.WriteCloser) {
+	switch writer := writer.(type) {
+	case *atomicWriter:
+		writer.abort()
+	case *teeWriter:
+		writer.abort()
+	default:
+		_ = writer.Close()
+	}
+}
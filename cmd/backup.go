@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type backupValue string
+
+func (b *backupValue) String() string {
+	return string(*b)
+}
+
+func (b *backupValue) Set(value string) error {
+	switch value {
+	case "simple", "numbered":
+		*b = backupValue(value)
+		return nil
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("must be true, false, simple or numbered")
+	}
+	*b = ""
+	if enabled {
+		*b = "simple"
+	}
+	return nil
+}
+
+func (b *backupValue) IsBoolFlag() bool {
+	return true
+}
+
+func backupPath(path, mode, suffix string) (string, error) {
+	if mode == "simple" {
+		return path + suffix, nil
+	}
+
+	matches, err := filepath.Glob(escapeGlob(path) + ".~*~")
+	if err != nil {
+		return "", err
+	}
+	last := 0
+	for _, match := range matches {
+		number, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(match, path+".~"), "~"))
+		if err == nil && number > last {
+			last = number
+		}
+	}
+	return fmt.Sprintf("%s.~%d~", path, last+1), nil
+}
+
+func escapeGlob(path string) string {
+	var builder strings.Builder
+	for _, r := range path {
+		if strings.ContainsRune(`*?[\`, r) {
+			builder.WriteRune('\\')
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
+
+func backupDestination(opts *Options) error {
+	backup, err := backupPath(opts.To, opts.Backup, opts.BackupSuffix)
+	if err != nil {
+		return fmt.Errorf("can not choose a backup name for %s: %w", opts.To, err)
+	}
+	if err = os.Rename(opts.To, backup); err != nil {
+		return fmt.Errorf("can not back up %s: %w", opts.To, err)
+	}
+	diag.infof("backed up %s to %s", opts.To, backup)
+	return nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"io"
 	"os"
 	"os/exec"
 	"strings"
@@ -18,7 +19,7 @@ func TestBasicConversions(t *testing.T) {
 	}()
 
 	t.Run("ok with stdin input and stdout result, offset with trim spaces", func(t *testing.T) {
-		cmd = exec.Command(binPath, "-offset", "4", "-conv", "trim_spaces")
+		cmd = exec.Command(binPath, "-quiet", "-offset", "4", "-conv", "trim_spaces")
 		cmd.Stdin = strings.NewReader("HEAD  BA  ")
 		stdout := &strings.Builder{}
 		cmd.Stdout = stdout
@@ -33,7 +34,7 @@ func TestBasicConversions(t *testing.T) {
 	})
 
 	t.Run("ok with stdin input and stdout result, limit with trim spaces", func(t *testing.T) {
-		cmd = exec.Command(binPath, "-limit", "6", "-conv", "trim_spaces")
+		cmd = exec.Command(binPath, "-quiet", "-limit", "6", "-conv", "trim_spaces")
 		cmd.Stdin = strings.NewReader("  BA  TAIL")
 		stdout := &strings.Builder{}
 		cmd.Stdout = stdout
@@ -48,7 +49,7 @@ func TestBasicConversions(t *testing.T) {
 	})
 
 	t.Run("ok with stdin input and stdout result, two conversions", func(t *testing.T) {
-		cmd = exec.Command(binPath, "-conv", "trim_spaces,lower_case")
+		cmd = exec.Command(binPath, "-quiet", "-conv", "trim_spaces,lower_case")
 		cmd.Stdin = strings.NewReader("  b ")
 		stdout := &strings.Builder{}
 		cmd.Stdout = stdout
@@ -63,7 +64,7 @@ func TestBasicConversions(t *testing.T) {
 	})
 
 	t.Run("ok, lower_case", func(t *testing.T) {
-		cmd = exec.Command(binPath, "-conv", "lower_case")
+		cmd = exec.Command(binPath, "-quiet", "-conv", "lower_case")
 		cmd.Stdin = strings.NewReader("WШ")
 		stdout := &strings.Builder{}
 		cmd.Stdout = stdout
@@ -78,7 +79,7 @@ func TestBasicConversions(t *testing.T) {
 	})
 
 	t.Run("ok, upper_case", func(t *testing.T) {
-		cmd = exec.Command(binPath, "-conv", "upper_case")
+		cmd = exec.Command(binPath, "-quiet", "-conv", "upper_case")
 		cmd.Stdin = strings.NewReader("wш")
 		stdout := &strings.Builder{}
 		cmd.Stdout = stdout
@@ -93,7 +94,7 @@ func TestBasicConversions(t *testing.T) {
 	})
 
 	t.Run("error with invalid conv", func(t *testing.T) {
-		cmd = exec.Command(binPath, "-conv", "qweqwe")
+		cmd = exec.Command(binPath, "-quiet", "-conv", "qweqwe")
 		cmd.Stdin = strings.NewReader(testInput)
 		stdout := &strings.Builder{}
 		cmd.Stdout = stdout
@@ -108,7 +109,7 @@ func TestBasicConversions(t *testing.T) {
 	})
 
 	t.Run("error due to contradictory conv", func(t *testing.T) {
-		cmd = exec.Command(binPath, "-conv", "upper_case,lower_case")
+		cmd = exec.Command(binPath, "-quiet", "-conv", "upper_case,lower_case")
 		cmd.Stdin = strings.NewReader(testInput)
 		stdout := &strings.Builder{}
 		cmd.Stdout = stdout
@@ -121,4 +122,152 @@ func TestBasicConversions(t *testing.T) {
 		assert.NotZero(t, stderr.Len())
 		assert.Zero(t, stdout.Len())
 	})
+
+	t.Run("ok, swap_case", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "swap_case", "-block-size", "1")
+		cmd.Stdin = strings.NewReader("hELlO Мир 42!")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, "HelLo мИР 42!", stdout.String())
+	})
+
+	t.Run("error, swap_case together with upper_case", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "swap_case,upper_case")
+		cmd.Stdin = strings.NewReader(testInput)
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.NotZero(t, stderr.Len())
+		assert.Zero(t, stdout.Len())
+	})
+
+	t.Run("ok, title_case with cyrillic and accented words", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "title_case", "-block-size", "1")
+		cmd.Stdin = strings.NewReader("éCOLE élÈVE-привет МИР, o'neil 2nd")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, "École Élève-Привет Мир, O'Neil 2Nd", stdout.String())
+	})
+
+	t.Run("error, title_case together with lower_case", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "lower_case,title_case")
+		cmd.Stdin = strings.NewReader(testInput)
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.NotZero(t, stderr.Len())
+		assert.Zero(t, stdout.Len())
+	})
+
+	t.Run("ok, upper_case and lower_case with turkish locale", func(t *testing.T) {
+		for _, tc := range []struct {
+			args     []string
+			input    string
+			expected string
+		}{
+			{[]string{"-conv", "upper_case", "-locale", "tr"}, "istanbul ıi", "İSTANBUL Iİ"},
+			{[]string{"-conv", "lower_case", "-locale", "tr"}, "İSTANBUL Iİ", "istanbul ıi"},
+			{[]string{"-conv", "upper_case"}, "istanbul ıi", "ISTANBUL II"},
+		} {
+			for _, blockSize := range []string{"1", "1024"} {
+				cmd = exec.Command(binPath, append(tc.args, "-quiet", "-block-size", blockSize)...)
+				cmd.Stdin = strings.NewReader(tc.input)
+				stdout := &strings.Builder{}
+				cmd.Stdout = stdout
+				stderr := &strings.Builder{}
+				cmd.Stderr = stderr
+
+				err := cmd.Run()
+
+				assert.NoError(t, err)
+				assert.Zero(t, stderr.Len(), stderr.String())
+				assert.Equal(t, tc.expected, stdout.String(), tc.args)
+			}
+		}
+	})
+
+	t.Run("fail, unknown locale", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "upper_case", "-locale", "xx")
+		cmd.Stdin = strings.NewReader("abc")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), `unknown -locale "xx", supported: az, el, lt, nl, tr`)
+	})
+
+	t.Run("ok, case conversions with ascii and non-ascii blocks", func(t *testing.T) {
+		input := "hello wORLD, привет МИР. title-case it"
+		for _, tc := range []struct {
+			conv     string
+			expected string
+		}{
+			{"upper_case", "HELLO WORLD, ПРИВЕТ МИР. TITLE-CASE IT"},
+			{"lower_case", "hello world, привет мир. title-case it"},
+			{"swap_case", "HELLO World, ПРИВЕТ мир. TITLE-CASE IT"},
+			{"title_case", "Hello World, Привет Мир. Title-Case It"},
+		} {
+			for _, blockSize := range []string{"1", "3", "7", "1024"} {
+				cmd = exec.Command(binPath, "-quiet", "-conv", tc.conv, "-block-size", blockSize)
+				cmd.Stdin = strings.NewReader(input)
+				stdout := &strings.Builder{}
+				cmd.Stdout = stdout
+				stderr := &strings.Builder{}
+				cmd.Stderr = stderr
+
+				err := cmd.Run()
+
+				assert.NoError(t, err)
+				assert.Zero(t, stderr.Len(), stderr.String())
+				assert.Equal(t, tc.expected, stdout.String(), tc.conv)
+			}
+		}
+	})
+}
+
+func BenchmarkCaseReader(b *testing.B) {
+	for _, bc := range []struct {
+		name  string
+		input string
+	}{
+		{"ascii", strings.Repeat("GET /index.html HTTP/1.1 200 ok\n", 1<<12)},
+		{"mixed", strings.Repeat("GET /индекс.html HTTP/1.1 200 ok\n", 1<<12)},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.SetBytes(int64(len(bc.input)))
+			for range b.N {
+				reader := &CaseReader{reader: strings.NewReader(bc.input), mode: upperCase}
+				_, err := io.CopyBuffer(io.Discard, reader, make([]byte, 1024))
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
 }
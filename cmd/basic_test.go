@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
@@ -65,7 +66,7 @@ func TestBasicIntegration(t *testing.T) {
 	}()
 
 	t.Run("ok with stdin input and stdout result", func(t *testing.T) {
-		cmd = exec.Command(binPath)
+		cmd = exec.Command(binPath, "-quiet")
 		cmd.Stdin = strings.NewReader(testInput)
 		stdout := &strings.Builder{}
 		cmd.Stdout = stdout
@@ -83,7 +84,7 @@ func TestBasicIntegration(t *testing.T) {
 		limit := 1000000
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
 		defer cancel()
-		cmd = exec.CommandContext(ctx, binPath, "-limit", strconv.Itoa(limit))
+		cmd = exec.CommandContext(ctx, binPath, "-quiet", "-limit", strconv.Itoa(limit))
 
 		cmd.Stdin = &unlimitedReader{input: []byte(testInput)}
 		stdout := &strings.Builder{}
@@ -106,7 +107,7 @@ func TestBasicIntegration(t *testing.T) {
 		_, err = testFile.WriteString(testInput)
 		assert.NoError(t, err)
 
-		cmd = exec.Command(binPath, "-from", testFile.Name())
+		cmd = exec.Command(binPath, "-quiet", "-from", testFile.Name())
 		stdout := &strings.Builder{}
 		cmd.Stdout = stdout
 		stderr := &strings.Builder{}
@@ -121,7 +122,7 @@ func TestBasicIntegration(t *testing.T) {
 
 	t.Run("ok with stdin input and file result", func(t *testing.T) {
 		testFileName := "out.txt"
-		cmd = exec.Command(binPath, "-to", testFileName)
+		cmd = exec.Command(binPath, "-quiet", "-to", testFileName)
 		cmd.Stdin = strings.NewReader(testInput)
 		stdout := &strings.Builder{}
 		cmd.Stdout = stdout
@@ -140,11 +141,55 @@ func TestBasicIntegration(t *testing.T) {
 		assert.Equal(t, testInput, string(data))
 	})
 
+	t.Run("error with existing file result", func(t *testing.T) {
+		testFileName := filepath.Join(t.TempDir(), "out.txt")
+		assert.NoError(t, os.WriteFile(testFileName, []byte("old"), 0o600))
+		cmd = exec.Command(binPath, "-quiet", "-to", testFileName)
+		cmd.Stdin = strings.NewReader(testInput)
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "destination already exists: "+testFileName)
+		data, err := os.ReadFile(testFileName)
+		assert.NoError(t, err)
+		assert.Equal(t, "old", string(data))
+	})
+
+	t.Run("error with file result in missing directory", func(t *testing.T) {
+		testFileName := filepath.Join(t.TempDir(), "missing", "out.txt")
+		cmd = exec.Command(binPath, "-quiet", "-to", testFileName)
+		cmd.Stdin = strings.NewReader(testInput)
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "parent directory "+filepath.Dir(testFileName)+" does not exist")
+	})
+
+	t.Run("error with directory result", func(t *testing.T) {
+		dir := t.TempDir()
+		cmd = exec.Command(binPath, "-quiet", "-to", dir)
+		cmd.Stdin = strings.NewReader(testInput)
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "destination "+dir+" is a directory")
+		assert.Contains(t, stderr.String(), "give a file name in -to when reading stdin")
+	})
+
 	t.Run("ok with stdin input and stdout result, limit and offset options", func(t *testing.T) {
 		limit := 100
 		offset := 1200
 		end := int(math.Min(float64(offset+limit), float64(len(testInput))))
-		cmd = exec.Command(binPath, "-limit", strconv.Itoa(limit), "-offset", strconv.Itoa(offset))
+		cmd = exec.Command(binPath, "-quiet", "-limit", strconv.Itoa(limit), "-offset", strconv.Itoa(offset))
 		cmd.Stdin = strings.NewReader(testInput)
 		stdout := &strings.Builder{}
 		cmd.Stdout = stdout
@@ -159,7 +204,7 @@ func TestBasicIntegration(t *testing.T) {
 	})
 
 	t.Run("error, offset greater than input size", func(t *testing.T) {
-		cmd = exec.Command(binPath, "-offset", "100", "-limit", "1000")
+		cmd = exec.Command(binPath, "-quiet", "-offset", "100", "-limit", "1000")
 		cmd.Stdin = strings.NewReader("test")
 		stdout := &strings.Builder{}
 		cmd.Stdout = stdout
@@ -174,7 +219,7 @@ func TestBasicIntegration(t *testing.T) {
 	})
 
 	t.Run("error with invalid limit", func(t *testing.T) {
-		cmd = exec.Command(binPath, "-limit", "qweqwe")
+		cmd = exec.Command(binPath, "-quiet", "-limit", "qweqwe")
 		stdout := &strings.Builder{}
 		cmd.Stdout = stdout
 		stderr := &strings.Builder{}
@@ -188,7 +233,7 @@ func TestBasicIntegration(t *testing.T) {
 	})
 
 	t.Run("error with invalid offset", func(t *testing.T) {
-		cmd = exec.Command(binPath, "-offset", "-90")
+		cmd = exec.Command(binPath, "-quiet", "-offset", "-90")
 		stdout := &strings.Builder{}
 		cmd.Stdout = stdout
 		stderr := &strings.Builder{}
@@ -202,7 +247,7 @@ func TestBasicIntegration(t *testing.T) {
 	})
 
 	t.Run("error with non-existent input file", func(t *testing.T) {
-		cmd = exec.Command(binPath, "-from", "non-exist.txt")
+		cmd = exec.Command(binPath, "-quiet", "-from", "non-exist.txt")
 		stdout := &strings.Builder{}
 		cmd.Stdout = stdout
 		stderr := &strings.Builder{}
@@ -216,7 +261,7 @@ func TestBasicIntegration(t *testing.T) {
 	})
 
 	t.Run("error with existing output file", func(t *testing.T) {
-		cmd = exec.Command(binPath, "-to", "main.go")
+		cmd = exec.Command(binPath, "-quiet", "-to", "main.go")
 		cmd.Stdin = strings.NewReader(testInput)
 		stdout := &strings.Builder{}
 		cmd.Stdout = stdout
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	textunicode "golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+var charsets = map[string]encoding.Encoding{
+	"utf-8":        textunicode.UTF8,
+	"utf-16le":     textunicode.UTF16(textunicode.LittleEndian, textunicode.IgnoreBOM),
+	"utf-16be":     textunicode.UTF16(textunicode.BigEndian, textunicode.IgnoreBOM),
+	"windows-1251": charmap.Windows1251,
+	"windows-1252": charmap.Windows1252,
+	"koi8-r":       charmap.KOI8R,
+	"koi8-u":       charmap.KOI8U,
+	"iso-8859-1":   charmap.ISO8859_1,
+	"iso-8859-5":   charmap.ISO8859_5,
+	"ibm866":       charmap.CodePage866,
+}
+
+var (
+	unwrappingConvs = []string{"bunzip2", "gunzip", "zstd_decode", "decrypt"}
+	wrappingConvs   = []string{"gzip", "zstd_encode", "encrypt"}
+)
+
+func lookupCharset(flagName, name string) (encoding.Encoding, error) {
+	if name == "" {
+		return nil, nil
+	}
+	charset, ok := charsets[strings.ToLower(name)]
+	if !ok {
+		names := make([]string, 0, len(charsets))
+		for known := range charsets {
+			names = append(names, known)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("%w: unknown %s %q, supported: %s", ErrInvalidFlag, flagName, name, strings.Join(names, ", "))
+	}
+	return charset, nil
+}
+
+func charsetBounds(convs []string) (decodeAt, encodeAt int) {
+	for decodeAt < len(convs) && slices.Contains(unwrappingConvs, convs[decodeAt]) {
+		decodeAt++
+	}
+	encodeAt = len(convs)
+	for encodeAt > decodeAt && slices.Contains(wrappingConvs, convs[encodeAt-1]) {
+		encodeAt--
+	}
+	return decodeAt, encodeAt
+}
+
+func newCharsetDecoder(reader io.Reader, charset encoding.Encoding) io.Reader {
+	if charset == nil {
+		return reader
+	}
+	diag.verbosef("added decoder from %s", charset)
+	return transform.NewReader(reader, charset.NewDecoder())
+}
+
+func newCharsetEncoder(reader io.Reader, charset encoding.Encoding, strict bool) io.Reader {
+	if charset == nil {
+		return reader
+	}
+	diag.verbosef("added encoder to %s", charset)
+	if strict {
+		return transform.NewReader(reader, charset.NewEncoder())
+	}
+	return transform.NewReader(reader, &questionMarkEncoder{encoder: charset.NewEncoder()})
+}
+
+type questionMarkEncoder struct {
+	encoder *encoding.Encoder
+}
+
+func (qe *questionMarkEncoder) Reset() {
+	qe.encoder.Reset()
+}
+
+func (qe *questionMarkEncoder) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	nDst, nSrc, err = qe.encoder.Transform(dst, src, atEOF)
+	for err != nil {
+		if _, ok := err.(interface{ Replacement() byte }); !ok {
+			return nDst, nSrc, err
+		}
+		if nDst == len(dst) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+		_, runeSize := utf8.DecodeRune(src[nSrc:])
+		dst[nDst] = '?'
+		nDst++
+		nSrc += runeSize
+
+		err = nil
+		if nSrc < len(src) {
+			var n, m int
+			n, m, err = qe.encoder.Transform(dst[nDst:], src[nSrc:], atEOF)
+			nDst += n
+			nSrc += m
+		}
+	}
+	return nDst, nSrc, nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCharsetConversions(t *testing.T) {
+	binPath := composeBinaryPath()
+	cmd := exec.Command("go", "build", "-o", binPath, "./")
+	assert.NoError(t, cmd.Run())
+	defer func() {
+		assert.NoError(t, os.Remove(binPath))
+	}()
+
+	t.Run("ok, windows-1251 to koi8-r with upper_case", func(t *testing.T) {
+		for _, blockSize := range []string{"1", "1024"} {
+			cmd = exec.Command(binPath, "-quiet", "-conv", "upper_case", "-output-encoding", "koi8-r",
+				"-input-encoding", "windows-1251", "-block-size", blockSize)
+			cmd.Stdin = strings.NewReader("\xef\xf0\xe8\xe2\xe5\xf2 ok")
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.NoError(t, err)
+			assert.Zero(t, stderr.Len(), stderr.String())
+			assert.Equal(t, "\xf0\xf2\xe9\xf7\xe5\xf4 OK", stdout.String())
+		}
+	})
+
+	t.Run("ok, utf-16le decoded after gunzip", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "gunzip,swap_case", "-input-encoding", "utf-16le")
+		cmd.Stdin = strings.NewReader(gzipped(t, "a\x00\x16\x04"))
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, "Aж", stdout.String())
+	})
+
+	t.Run("ok, unmappable runes replaced with question mark", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-output-encoding", "iso-8859-1")
+		cmd.Stdin = strings.NewReader("café 😊 ж")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, "caf\xe9 ? ?", stdout.String())
+	})
+
+	t.Run("error, unmappable rune with -encoding-strict", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-output-encoding", "iso-8859-1", "-encoding-strict")
+		cmd.Stdin = strings.NewReader("café ж")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "rune not supported")
+	})
+
+	t.Run("fail, unknown encoding", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-input-encoding", "cp9999")
+		cmd.Stdin = strings.NewReader("abc")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), `unknown -input-encoding "cp9999", supported: ibm866, iso-8859-1`)
+	})
+}
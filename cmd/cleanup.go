@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+type destinationState struct {
+	path     string
+	existing os.FileInfo
+}
+
+// snapshotDestinations remembers which destinations the copy is about to
+// create and the size and mtime of the regular files it may overwrite.
+func snapshotDestinations(opts *Options) []destinationState {
+	var states []destinationState
+	for _, path := range append([]string{opts.To}, opts.Tee...) {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			states = append(states, destinationState{path: path})
+		} else if stat, err := os.Stat(path); err == nil && stat.Mode().IsRegular() {
+			states = append(states, destinationState{path: path, existing: stat})
+		}
+	}
+	return states
+}
+
+func handlePartialOutput(opts *Options, states []destinationState, err error) error {
+	for _, state := range states {
+		if state.existing != nil {
+			stat, statErr := os.Stat(state.path)
+			if statErr == nil && (stat.Size() != state.existing.Size() || !stat.ModTime().Equal(state.existing.ModTime())) {
+				err = fmt.Errorf("%w, partial output kept at %s", err, state.path)
+			}
+			continue
+		}
+		if _, statErr := os.Lstat(state.path); statErr != nil {
+			continue
+		}
+		if !opts.CleanupOnError {
+			err = fmt.Errorf("%w, partial %s kept", err, state.path)
+			continue
+		}
+		if removeErr := os.Remove(state.path); removeErr != nil {
+			diag.errorf("can not remove partial %s: %v", state.path, removeErr)
+			err = fmt.Errorf("%w, partial %s kept", err, state.path)
+			continue
+		}
+		err = fmt.Errorf("%w, partial %s removed", err, state.path)
+	}
+	return err
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var ErrDecompression = fmt.Errorf("error while decompressing")
+
+func newCompressReader(reader io.Reader, newWriter func(io.Writer) (io.WriteCloser, error)) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		cw, err := newWriter(pw)
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		_, err = io.Copy(cw, reader)
+		if err == nil {
+			err = cw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
+
+func newGzipReader(reader io.Reader, level int) io.Reader {
+	return newCompressReader(reader, func(w io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriterLevel(w, level)
+	})
+}
+
+func newZstdReader(reader io.Reader, level int) io.Reader {
+	return newCompressReader(reader, func(w io.Writer) (io.WriteCloser, error) {
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)), zstd.WithEncoderConcurrency(1))
+	})
+}
+
+type GunzipReader struct {
+	reader io.Reader
+	gzip   *gzip.Reader
+}
+
+func (gr *GunzipReader) Read(p []byte) (n int, err error) {
+	if gr.gzip == nil {
+		gr.gzip, err = gzip.NewReader(gr.reader)
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%w: gunzip: %w", ErrDecompression, err)
+		}
+	}
+
+	n, err = gr.gzip.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return n, fmt.Errorf("%w: gunzip: %w", ErrDecompression, err)
+	}
+	return n, err
+}
+
+type ZstdDecodeReader struct {
+	reader  io.Reader
+	decoder *zstd.Decoder
+}
+
+func (zr *ZstdDecodeReader) Read(p []byte) (n int, err error) {
+	if zr.decoder == nil {
+		zr.decoder, err = zstd.NewReader(zr.reader, zstd.WithDecoderConcurrency(1), zstd.WithDecoderLowmem(true))
+		if err != nil {
+			return 0, fmt.Errorf("%w: zstd: %w", ErrDecompression, err)
+		}
+	}
+
+	n, err = zr.decoder.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return n, fmt.Errorf("%w: zstd: %w", ErrDecompression, err)
+	}
+	return n, err
+}
+
+type Bunzip2Reader struct {
+	reader io.Reader
+	bzip2  io.Reader
+}
+
+func (br *Bunzip2Reader) Read(p []byte) (n int, err error) {
+	if br.bzip2 == nil {
+		br.bzip2 = bzip2.NewReader(br.reader)
+	}
+
+	n, err = br.bzip2.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return n, fmt.Errorf("%w: bunzip2: %w", ErrDecompression, err)
+	}
+	return n, err
+}
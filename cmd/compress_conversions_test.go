@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 400 lines of "0123456789abcdef\n" compressed with bzip2.
+const bzip2Input = "QlpoOTFBWSZTWRIFpfEAAY/JAAAQf+A/ACAAkChppgAClUCYCY9CLAIsQiyCLMItAi/BFqEWwRbhFwEXIRdBF2EX8IvAi9CL4XckU4UJASBaXxA="
+
+func gzipped(t *testing.T, data string) string {
+	buffer := &bytes.Buffer{}
+	gw := gzip.NewWriter(buffer)
+	_, err := gw.Write([]byte(data))
+	assert.NoError(t, err)
+	assert.NoError(t, gw.Close())
+	return buffer.String()
+}
+
+func TestCompressConversions(t *testing.T) {
+	binPath := composeBinaryPath()
+	cmd := exec.Command("go", "build", "-o", binPath, "./")
+	assert.NoError(t, cmd.Run())
+	defer func() {
+		assert.NoError(t, os.Remove(binPath))
+	}()
+
+	t.Run("ok, gzip to file", func(t *testing.T) {
+		testFileName := "out.gz"
+		cmd = exec.Command(binPath, "-quiet", "-conv", "trim_spaces,gzip", "-gzip-level", "9", "-block-size", "5", "-to", testFileName)
+		cmd.Stdin = strings.NewReader(testInput)
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+
+		file, err := os.Open(testFileName)
+		defer os.Remove(testFileName)
+		assert.NoError(t, err)
+		defer file.Close()
+		gr, err := gzip.NewReader(file)
+		assert.NoError(t, err)
+		data, err := io.ReadAll(gr)
+		assert.NoError(t, err)
+		assert.Equal(t, strings.TrimSpace(testInput), string(data))
+	})
+
+	t.Run("ok, gzip of empty input", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "gzip")
+		cmd.Stdin = strings.NewReader("")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		gr, err := gzip.NewReader(strings.NewReader(stdout.String()))
+		assert.NoError(t, err)
+		data, err := io.ReadAll(gr)
+		assert.NoError(t, err)
+		assert.Empty(t, data)
+	})
+
+	t.Run("error with invalid gzip-level", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "gzip", "-gzip-level", "10")
+		cmd.Stdin = strings.NewReader(testInput)
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.NotZero(t, stderr.Len())
+		assert.Zero(t, stdout.Len())
+	})
+
+	t.Run("ok, gunzip with offset applied to compressed input", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "gunzip", "-offset", "6", "-block-size", "3")
+		cmd.Stdin = strings.NewReader("HEADER" + gzipped(t, testInput))
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, testInput, stdout.String())
+	})
+
+	t.Run("error, gunzip with limit applied to compressed input", func(t *testing.T) {
+		compressed := gzipped(t, testInput)
+		cmd = exec.Command(binPath, "-quiet", "-conv", "gunzip", "-limit", strconv.Itoa(len(compressed)-8))
+		cmd.Stdin = strings.NewReader(compressed)
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "error while decompressing")
+	})
+
+	t.Run("error, gunzip with corrupt input", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "gunzip")
+		cmd.Stdin = strings.NewReader("definitely not gzip")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "error while decompressing")
+		assert.Zero(t, stdout.Len())
+	})
+
+	t.Run("ok, zstd_encode after trim_spaces round-trips through zstd_decode", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "trim_spaces,zstd_encode", "-zstd-level", "19", "-block-size", "7")
+		cmd.Stdin = strings.NewReader(testInput)
+		compressed := &strings.Builder{}
+		cmd.Stdout = compressed
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+
+		cmd = exec.Command(binPath, "-quiet", "-conv", "zstd_decode", "-block-size", "1")
+		cmd.Stdin = strings.NewReader(compressed.String())
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+
+		err = cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, strings.TrimSpace(testInput), stdout.String())
+	})
+
+	t.Run("error, zstd_decode with truncated frame", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "zstd_encode")
+		cmd.Stdin = strings.NewReader(testInput)
+		compressed := &strings.Builder{}
+		cmd.Stdout = compressed
+
+		assert.NoError(t, cmd.Run())
+
+		cmd = exec.Command(binPath, "-quiet", "-conv", "zstd_decode", "-limit", strconv.Itoa(compressed.Len()-5))
+		cmd.Stdin = strings.NewReader(compressed.String())
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "error while decompressing")
+	})
+
+	t.Run("error with invalid zstd-level", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "zstd_encode", "-zstd-level", "0")
+		cmd.Stdin = strings.NewReader(testInput)
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.NotZero(t, stderr.Len())
+		assert.Zero(t, stdout.Len())
+	})
+
+	t.Run("ok, bunzip2 with offset and limit applied to decompressed data", func(t *testing.T) {
+		compressed, err := base64.StdEncoding.DecodeString(bzip2Input)
+		assert.NoError(t, err)
+		decompressed := strings.Repeat("0123456789abcdef\n", 400)
+
+		cmd = exec.Command(binPath, "-quiet", "-conv", "bunzip2", "-offset", "1024", "-limit", "4096", "-block-size", "1")
+		cmd.Stdin = bytes.NewReader(compressed)
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err = cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, decompressed[1024:1024+4096], stdout.String())
+	})
+
+	t.Run("error, bunzip2 with bad magic", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "bunzip2")
+		cmd.Stdin = strings.NewReader(testInput)
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "magic")
+		assert.Zero(t, stdout.Len())
+	})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+type pathsValue []string
+
+func (p *pathsValue) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *pathsValue) Set(value string) error {
+	*p = append(*p, value)
+	return nil
+}
+
+func expandGlobs(patterns []string) ([]string, error) {
+	var paths []string
+	for _, pattern := range patterns {
+		if !strings.ContainsAny(pattern, "*?[") {
+			paths = append(paths, pattern)
+			continue
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid -from pattern %q: %w", ErrInvalidFlag, pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("%w: -from pattern %q matches no files, use -no-glob to open it literally", ErrInvalidFlag, pattern)
+		}
+		slices.Sort(matches)
+		paths = append(paths, matches...)
+	}
+	return paths, nil
+}
+
+type openSourceError struct {
+	path string
+	err  error
+}
+
+func (e *openSourceError) Error() string {
+	return fmt.Sprintf("can not open %s: %v", e.path, e.err)
+}
+
+func (e *openSourceError) Unwrap() error {
+	return e.err
+}
+
+type ConcatReader struct {
+	current *os.File
+	paths   []string
+}
+
+func (cr *ConcatReader) Read(p []byte) (n int, err error) {
+	if cr.current == nil {
+		if len(cr.paths) == 0 {
+			return 0, io.EOF
+		}
+		cr.current, err = os.Open(cr.paths[0])
+		if err != nil {
+			cr.current = nil
+			return 0, &openSourceError{path: cr.paths[0], err: err}
+		}
+		diag.verbosef("opened source %s", cr.paths[0])
+		cr.paths = cr.paths[1:]
+	}
+
+	n, err = cr.current.Read(p)
+	if errors.Is(err, io.EOF) {
+		_ = cr.current.Close()
+		cr.current = nil
+		if n == 0 {
+			return cr.Read(p)
+		}
+		return n, nil
+	}
+	return n, err
+}
+
+func (cr *ConcatReader) Close() error {
+	if cr.current == nil {
+		return nil
+	}
+	return cr.current.Close()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+func applyConfig(path string, origins map[string]string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("%w: can not read -config: %w", ErrInvalidFlag, err)
+	}
+	var config map[string]any
+	if err = yaml.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("%w: can not parse -config %s: %w", ErrInvalidFlag, path, err)
+	}
+
+	explicit := setFlags()
+
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		name := strings.ReplaceAll(key, "_", "-")
+		f := flag.Lookup(name)
+		if f == nil || name == "config" {
+			return fmt.Errorf("%w: unknown key %q in -config %s", ErrInvalidFlag, key, path)
+		}
+		if explicit[name] {
+			continue
+		}
+		values, err := configValues(config[key], f)
+		if err != nil {
+			return fmt.Errorf("%w: key %q in -config %s: %w", ErrInvalidFlag, key, path, err)
+		}
+		for _, value := range values {
+			if err = flag.Set(name, value); err != nil {
+				return fmt.Errorf("%w: invalid value %q of key %q in -config %s: %w", ErrInvalidFlag, value, key, path, err)
+			}
+		}
+		origins[name] = fmt.Sprintf("key %s of -config %s", key, path)
+	}
+	return nil
+}
+
+func configValues(value any, f *flag.Flag) ([]string, error) {
+	switch value := value.(type) {
+	case nil:
+		return nil, fmt.Errorf("value is missing")
+	case map[string]any:
+		return nil, fmt.Errorf("expected a value or a list, got a mapping")
+	case []any:
+		values := make([]string, len(value))
+		for i, item := range value {
+			switch item.(type) {
+			case nil, map[string]any, []any:
+				return nil, fmt.Errorf("list items must be values")
+			}
+			values[i] = fmt.Sprint(item)
+		}
+		if _, repeatable := f.Value.(*pathsValue); repeatable {
+			return values, nil
+		}
+		return []string{strings.Join(values, ",")}, nil
+	default:
+		return []string{fmt.Sprint(value)}, nil
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+type countingReader struct {
+	reader io.Reader
+	total  int64
+	read   atomic.Int64
+}
+
+func (cr *countingReader) Read(p []byte) (n int, err error) {
+	n, err = cr.reader.Read(p)
+	cr.read.Add(int64(n))
+	return n, err
+}
+
+type countingReaderAt struct {
+	reader  io.ReaderAt
+	counter *countingReader
+}
+
+func (cr *countingReaderAt) ReadAt(p []byte, off int64) (n int, err error) {
+	n, err = cr.reader.ReadAt(p, off)
+	cr.counter.read.Add(int64(n))
+	return n, err
+}
+
+type recordCount struct {
+	full    uint64
+	partial uint64
+}
+
+func (rc *recordCount) add(n, blockSize int) {
+	if n == blockSize {
+		rc.full++
+	} else {
+		rc.partial++
+	}
+}
+
+func (rc recordCount) String() string {
+	return fmt.Sprintf("%d+%d", rc.full, rc.partial)
+}
+
+type blockCopier struct {
+	reader      io.Reader
+	writer      io.Writer
+	blockSize   uint64
+	count       uint64
+	sync        bool
+	direct      bool
+	digests     digests
+	verify      hash.Hash
+	maxRate     uint64
+	retry       *RetryReader
+	noError     *NoErrorReader
+	errorMap    string
+	fullBlock   bool
+	outputLimit int64
+	runeOutput  bool
+	source      *countingReader
+	started     time.Time
+	written     atomic.Int64
+	reading     atomic.Bool
+	recordsIn   recordCount
+	recordsOut  recordCount
+
+	// pending collects read blocks into blocks of its capacity before they
+	// are written, nil when both sides use the same block size.
+	pending []byte
+}
+
+func (bc *blockCopier) copy() error {
+	err := bc.copyBlocks()
+	if errors.Is(err, errOutputLimit) {
+		return nil
+	}
+	return err
+}
+
+func (bc *blockCopier) copyBlocks() error {
+	buffer := make([]byte, bc.blockSize)
+	if bc.direct {
+		buffer = alignedBuffer(int(bc.blockSize))
+	}
+	for blocks := uint64(0); blocks < bc.count; {
+		if isInterrupted() {
+			return bc.flush(ErrInterrupted)
+		}
+		n, err := bc.read(buffer)
+		if n > 0 {
+			blocks++
+			bc.recordsIn.add(n, len(buffer))
+
+			block := buffer[:n]
+			if bc.sync && n < len(buffer) {
+				clear(buffer[n:])
+				block = buffer
+			}
+			if writeErr := bc.write(block); writeErr != nil {
+				return writeErr
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			return bc.flush(nil)
+		}
+		if err != nil && isInterrupted() {
+			return bc.flush(ErrInterrupted)
+		}
+		if err != nil {
+			return bc.flush(readError(err))
+		}
+	}
+	return bc.flush(nil)
+}
+
+func (bc *blockCopier) write(block []byte) error {
+	if bc.pending == nil {
+		return bc.writeBlock(block, int(bc.blockSize))
+	}
+	size := cap(bc.pending)
+	for len(bc.pending) == 0 && len(block) >= size {
+		if err := bc.writeBlock(block[:size], size); err != nil {
+			return err
+		}
+		block = block[size:]
+	}
+	for len(block) > 0 {
+		n := copy(bc.pending[len(bc.pending):size], block)
+		bc.pending, block = bc.pending[:len(bc.pending)+n], block[n:]
+		if len(bc.pending) == size {
+			if err := bc.writeBlock(bc.pending, size); err != nil {
+				return err
+			}
+			bc.pending = bc.pending[:0]
+		}
+	}
+	return nil
+}
+
+// flush writes what is left in pending and passes err through. A failed
+// write is returned only when there is no earlier error.
+func (bc *blockCopier) flush(err error) error {
+	if len(bc.pending) == 0 {
+		return err
+	}
+	writeErr := bc.writeBlock(bc.pending, cap(bc.pending))
+	bc.pending = bc.pending[:0]
+	if err == nil {
+		return writeErr
+	}
+	return err
+}
+
+func (bc *blockCopier) writeBlock(block []byte, blockSize int) error {
+	limited := false
+	if remaining := bc.outputLimit - bc.written.Load(); int64(len(block)) >= remaining {
+		block, limited = cutOutput(block, remaining, bc.runeOutput), true
+		if len(block) == 0 {
+			return errOutputLimit
+		}
+	}
+
+	written, err := bc.writer.Write(block)
+	bc.written.Add(int64(written))
+	_, _ = bc.digests.Write(block[:written])
+	if bc.verify != nil {
+		bc.verify.Write(block[:written])
+	}
+	if err != nil {
+		return destinationError(err)
+	}
+	if written < len(block) {
+		return destinationError(io.ErrShortWrite)
+	}
+	bc.recordsOut.add(written, blockSize)
+	if limited {
+		return errOutputLimit
+	}
+	return nil
+}
+
+func (bc *blockCopier) read(buffer []byte) (int, error) {
+	bc.reading.Store(true)
+	defer bc.reading.Store(false)
+	if !bc.fullBlock {
+		return bc.reader.Read(buffer)
+	}
+	n, err := io.ReadFull(bc.reader, buffer)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (bc *blockCopier) summary(partial bool) string {
+	elapsed := time.Since(bc.started).Seconds()
+	written := bc.written.Load()
+	prefix := ""
+	if partial {
+		prefix = "partial: "
+	}
+	summary := fmt.Sprintf("%s%d bytes read, %d bytes written, %d blocks, %.3f s, %.1f MB/s",
+		prefix, bc.source.read.Load(), written, bc.recordsOut.full+bc.recordsOut.partial, elapsed, float64(written)/elapsed/1e6)
+	if sparse, ok := bc.writer.(*sparseWriter); ok {
+		summary += fmt.Sprintf(", %d bytes skipped as holes", sparse.holes)
+	}
+	if bc.maxRate != 0 {
+		summary += fmt.Sprintf(", read %s/s of -max-rate %s/s",
+			formatSize(int64(float64(bc.source.read.Load())/elapsed)), formatSize(int64(bc.maxRate)))
+	}
+	if bc.retry != nil && bc.retry.count != 0 {
+		summary += fmt.Sprintf(", %d read retries", bc.retry.count)
+	}
+	if bc.noError != nil {
+		summary += bc.noError.summary(bc.errorMap)
+	}
+	if tee, ok := bc.writer.(*teeWriter); ok {
+		summary += tee.summary()
+	}
+	return summary
+}
@@ -0,0 +1,2192 @@
+package main
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopyOptions(t *testing.T) {
+	binPath := composeBinaryPath()
+	cmd := exec.Command("go", "build", "-o", binPath, "./")
+	assert.NoError(t, cmd.Run())
+	defer func() {
+		assert.NoError(t, os.Remove(binPath))
+	}()
+
+	t.Run("ok, sync pads every short block", func(t *testing.T) {
+		inputFile := filepath.Join(t.TempDir(), "in")
+		assert.NoError(t, os.WriteFile(inputFile, []byte("0123456789"), 0o600))
+
+		cmd = exec.Command(binPath, "-from", inputFile, "-conv", "sync", "-block-size", "4")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(stderr.String(), "12 bytes written\n"), stderr.String())
+		assert.Equal(t, "0123456789\x00\x00", stdout.String())
+	})
+
+	t.Run("ok, sync with empty input", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-conv", "sync", "-block-size", "4")
+		cmd.Stdin = strings.NewReader("")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(stderr.String(), "0 bytes written\n"), stderr.String())
+		assert.Zero(t, stdout.Len())
+	})
+
+	t.Run("ok, append accumulates output", func(t *testing.T) {
+		outputFile := filepath.Join(t.TempDir(), "out")
+		for _, input := range []string{"first\n", "second\n"} {
+			cmd = exec.Command(binPath, "-quiet", "-to", outputFile, "-append", "-conv", "upper_case")
+			cmd.Stdin = strings.NewReader(input)
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.NoError(t, err)
+			assert.Zero(t, stderr.Len(), stderr.String())
+		}
+
+		data, err := os.ReadFile(outputFile)
+		assert.NoError(t, err)
+		assert.Equal(t, "FIRST\nSECOND\n", string(data))
+	})
+
+	t.Run("fail, append without -to", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-append")
+		cmd.Stdin = strings.NewReader("abc")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Zero(t, stdout.Len())
+		assert.Contains(t, stderr.String(), "-append requires -to")
+	})
+
+	t.Run("ok, force overwrites file behind symlink", func(t *testing.T) {
+		dir := t.TempDir()
+		outputFile := filepath.Join(dir, "out")
+		link := filepath.Join(dir, "link")
+		assert.NoError(t, os.WriteFile(outputFile, []byte("old and longer content"), 0o600))
+		assert.NoError(t, os.Symlink(outputFile, link))
+
+		cmd = exec.Command(binPath, "-quiet", "-to", link)
+		cmd.Stdin = strings.NewReader("new")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "destination already exists: "+outputFile+", use -force to overwrite it")
+
+		cmd = exec.Command(binPath, "-quiet", "-to", link, "-force")
+		cmd.Stdin = strings.NewReader("new")
+		stderr = &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err = cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		data, err := os.ReadFile(outputFile)
+		assert.NoError(t, err)
+		assert.Equal(t, "new", string(data))
+	})
+
+	t.Run("ok, seek patches a region of the destination", func(t *testing.T) {
+		dir := t.TempDir()
+		inputFile := filepath.Join(dir, "in")
+		outputFile := filepath.Join(dir, "out")
+		assert.NoError(t, os.WriteFile(inputFile, []byte("xxABCDxx"), 0o600))
+		assert.NoError(t, os.WriteFile(outputFile, []byte("0123456789"), 0o600))
+
+		cmd = exec.Command(binPath, "-quiet", "-from", inputFile, "-to", outputFile, "-offset", "2", "-limit", "4", "-seek", "3")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		data, err := os.ReadFile(outputFile)
+		assert.NoError(t, err)
+		assert.Equal(t, "012ABCD789", string(data))
+	})
+
+	t.Run("fail, seek into stdout", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-seek", "3")
+		cmd.Stdin = strings.NewReader("abc")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Zero(t, stdout.Len())
+		assert.Contains(t, stderr.String(), "-seek requires a seekable -to")
+	})
+
+	t.Run("ok, negative offset reads from the end of the file", func(t *testing.T) {
+		inputFile := filepath.Join(t.TempDir(), "in")
+		assert.NoError(t, os.WriteFile(inputFile, []byte("0123456789"), 0o600))
+
+		for _, tc := range []struct {
+			args     []string
+			expected string
+		}{
+			{[]string{"-offset", "-4"}, "6789"},
+			{[]string{"-offset", "-4", "-limit", "2"}, "67"},
+			{[]string{"-offset", "-100"}, "0123456789"},
+			{[]string{"-offset", "-3", "-conv", "reverse_lines"}, "789\n"},
+		} {
+			cmd = exec.Command(binPath, append([]string{"-quiet", "-from", inputFile}, tc.args...)...)
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.NoError(t, err)
+			assert.Zero(t, stderr.Len(), stderr.String())
+			assert.Equal(t, tc.expected, stdout.String(), tc.args)
+		}
+	})
+
+	t.Run("fail, negative offset with stdin", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-offset", "-4")
+		cmd.Stdin = strings.NewReader("0123456789")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Zero(t, stdout.Len())
+		assert.Contains(t, stderr.String(), "negative -offset requires a regular file in -from")
+	})
+
+	t.Run("ok, count stops after N blocks", func(t *testing.T) {
+		inputFile := filepath.Join(t.TempDir(), "in")
+		assert.NoError(t, os.WriteFile(inputFile, []byte("0123456789"), 0o600))
+
+		for _, tc := range []struct {
+			count    string
+			expected string
+			records  string
+		}{
+			{"2", "01234567", "2+0 records in\n2+0 records out\n"},
+			{"5", "0123456789", "2+1 records in\n2+1 records out\n"},
+			{"0", "", "0+0 records in\n0+0 records out\n"},
+		} {
+			cmd = exec.Command(binPath, "-from", inputFile, "-block-size", "4", "-count", tc.count)
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(stderr.String(), tc.records), stderr.String())
+			assert.Equal(t, tc.expected, stdout.String(), tc.count)
+		}
+	})
+
+	t.Run("fail, count together with limit", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-count", "1", "-limit", "10")
+		cmd.Stdin = strings.NewReader("abc")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "-count and -limit cannot be used at the same time")
+	})
+
+	t.Run("ok, skip-blocks and seek-blocks", func(t *testing.T) {
+		dir := t.TempDir()
+		inputFile := filepath.Join(dir, "in")
+		outputFile := filepath.Join(dir, "out")
+		assert.NoError(t, os.WriteFile(inputFile, []byte("0123456789"), 0o600))
+		assert.NoError(t, os.WriteFile(outputFile, []byte("abcdefghijkl"), 0o600))
+
+		cmd = exec.Command(binPath, "-quiet", "-from", inputFile, "-to", outputFile,
+			"-block-size", "3", "-skip-blocks", "1", "-seek-blocks", "2", "-count", "1")
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		data, err := os.ReadFile(outputFile)
+		assert.NoError(t, err)
+		assert.Equal(t, "abcdef345jkl", string(data))
+	})
+
+	t.Run("ok, skip-blocks from stdin and seek-blocks into stdout pipe", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-block-size", "2", "-skip-blocks", "2", "-seek-blocks", "1")
+		cmd.Stdin = strings.NewReader("0123456789")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, "\x00\x00456789", stdout.String())
+	})
+
+	t.Run("fail, skip-blocks together with offset", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-skip-blocks", "1", "-offset", "10")
+		cmd.Stdin = strings.NewReader("abc")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "-skip-blocks and -offset cannot be used at the same time")
+	})
+
+	t.Run("ok, size suffixes", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-offset", "1K", "-limit", "0.5K", "-block-size", "1kB")
+		cmd.Stdin = strings.NewReader(strings.Repeat("a", 1024) + strings.Repeat("b", 1024))
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, strings.Repeat("b", 512), stdout.String())
+	})
+
+	t.Run("fail, invalid size suffix", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-block-size", "4X")
+		cmd.Stdin = strings.NewReader("abc")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), `invalid value "4X" for flag -block-size`)
+	})
+
+	t.Run("ok, dd-style operands mixed with flags", func(t *testing.T) {
+		dir := t.TempDir()
+		inputFile := filepath.Join(dir, "in")
+		outputFile := filepath.Join(dir, "out")
+		assert.NoError(t, os.WriteFile(inputFile, []byte("0123456789abcdef"), 0o600))
+
+		cmd = exec.Command(binPath, "-quiet", "-block-size", "4", "if="+inputFile, "bs=2", "skip=1",
+			"-conv", "upper_case", "of="+outputFile, "conv=lower_case", "count=2")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		data, err := os.ReadFile(outputFile)
+		assert.NoError(t, err)
+		assert.Equal(t, "456789AB", string(data))
+	})
+
+	t.Run("fail, unknown dd-style operand", func(t *testing.T) {
+		for _, operand := range []string{"ibs=4", "obs=4"} {
+			cmd = exec.Command(binPath, "-quiet", operand)
+			cmd.Stdin = strings.NewReader("abc")
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.Error(t, err)
+			assert.Contains(t, stderr.String(), "unknown operand "+strconv.Quote(operand)+", supported: bs=, conv=")
+		}
+	})
+
+	t.Run("ok, progress goes to stderr only", func(t *testing.T) {
+		inputFile := filepath.Join(t.TempDir(), "in")
+		assert.NoError(t, os.WriteFile(inputFile, []byte(testInput), 0o600))
+
+		cmd = exec.Command(binPath, "-quiet", "-from", inputFile, "-progress", "-offset", "100", "-limit", "1K")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Equal(t, testInput[100:1124], stdout.String())
+		assert.True(t, strings.HasPrefix(stderr.String(), "\r1.0 KiB copied (100.0%), "), stderr.String())
+		assert.True(t, strings.HasSuffix(stderr.String(), "\n"), stderr.String())
+	})
+
+	t.Run("ok, summary with bytes read and written", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-conv", "hex_encode", "-block-size", "4")
+		cmd.Stdin = strings.NewReader("abcdef")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "616263646566", stdout.String())
+		assert.Regexp(t, `^6 bytes read, 12 bytes written, 3 blocks, \d+\.\d{3} s, \d+\.\d MB/s\n$`, stderr.String())
+	})
+
+	t.Run("error, partial summary when the copy fails midway", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-conv", "base64_decode", "-block-size", "4")
+		cmd.Stdin = strings.NewReader("YWJj!!!!")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "illegal base64 character")
+		assert.Regexp(t, `\npartial: \d+ bytes read, \d+ bytes written, `, stderr.String())
+	})
+
+	t.Run("ok, verbose logs every stage on stderr only", func(t *testing.T) {
+		dir := t.TempDir()
+		inputFile := filepath.Join(dir, "in")
+		outputFile := filepath.Join(dir, "out")
+		assert.NoError(t, os.WriteFile(inputFile, []byte("0123456789"), 0o600))
+
+		cmd = exec.Command(binPath, "-verbose", "-from", inputFile, "-to", outputFile, "-offset", "2", "-conv", "upper_case,trim_spaces")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stdout.Len())
+		assert.Contains(t, stderr.String(), "opened source "+inputFile+"\n"+
+			"skipped 2 bytes of input via seek\n"+
+			"added conv upper_case\n"+
+			"added conv trim_spaces\n"+
+			"created destination "+outputFile+"\n")
+		assert.Contains(t, stderr.String(), "8 bytes read, 8 bytes written, ")
+	})
+
+	t.Run("ok, quiet prints nothing", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-offset", "1")
+		cmd.Stdin = strings.NewReader("abc")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "bc", stdout.String())
+		assert.Zero(t, stderr.Len())
+
+		cmd = exec.Command(binPath, "-quiet", "-count", "1")
+		cmd.Stdin = strings.NewReader("1\n2\n3\n")
+		stdout.Reset()
+		cmd.Stdout = stdout
+		stderr.Reset()
+		cmd.Stderr = stderr
+
+		assert.NoError(t, cmd.Run())
+		assert.Equal(t, "1\n2\n3\n", stdout.String())
+		assert.Zero(t, stderr.Len(), stderr.String())
+	})
+
+	t.Run("fail, quiet together with verbose", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-verbose")
+		cmd.Stdin = strings.NewReader("abc")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "-quiet and -verbose cannot be used at the same time")
+	})
+
+	t.Run("ok, dry run reports the copy without creating the destination", func(t *testing.T) {
+		dir := t.TempDir()
+		inputFile := filepath.Join(dir, "in")
+		outputFile := filepath.Join(dir, "out")
+		assert.NoError(t, os.WriteFile(inputFile, []byte("0123456789"), 0o600))
+
+		cmd = exec.Command(binPath, "-dry-run", "-from", inputFile, "-to", outputFile, "-offset", "2", "-limit", "5", "-conv", "upper_case")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "source: "+inputFile+", 10 bytes\n"+
+			"destination: "+outputFile+", will be created\n"+
+			"conv: upper_case\n"+
+			"bytes to copy: 5\n", stdout.String())
+		assert.NoFileExists(t, outputFile)
+	})
+
+	t.Run("fail, dry run over an existing destination without -force", func(t *testing.T) {
+		outputFile := filepath.Join(t.TempDir(), "out")
+		assert.NoError(t, os.WriteFile(outputFile, []byte("keep"), 0o600))
+
+		cmd = exec.Command(binPath, "-dry-run", "-to", outputFile)
+		cmd.Stdin = strings.NewReader("abc")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "destination already exists")
+		data, err := os.ReadFile(outputFile)
+		assert.NoError(t, err)
+		assert.Equal(t, "keep", string(data))
+	})
+
+	t.Run("ok, version ignores invalid flag combinations", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-version", "-quiet", "-verbose", "-count", "1", "-limit", "2")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Regexp(t, `^copying-files-utility \S+\ncommit: \S+\ngo: go\S+\n$`, stdout.String())
+	})
+
+	t.Run("ok, version as json", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-version-json")
+		output, err := cmd.Output()
+		assert.NoError(t, err)
+
+		var build map[string]string
+		assert.NoError(t, json.Unmarshal(output, &build))
+		assert.Equal(t, runtime.Version(), build["go"])
+		assert.NotEmpty(t, build["version"])
+		assert.NotEmpty(t, build["commit"])
+	})
+
+	t.Run("ok, several -from are concatenated before conv", func(t *testing.T) {
+		dir := t.TempDir()
+		var args []string
+		for i, part := range []string{"  first ", "  ", " second  "} {
+			partFile := filepath.Join(dir, "part-00"+strconv.Itoa(i))
+			assert.NoError(t, os.WriteFile(partFile, []byte(part), 0o600))
+			args = append(args, "-from", partFile)
+		}
+
+		cmd = exec.Command(binPath, append(args, "-quiet", "-offset", "1", "-conv", "trim_spaces")...)
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "first    second", stdout.String())
+	})
+
+	t.Run("fail, missing file in the middle of several -from", func(t *testing.T) {
+		dir := t.TempDir()
+		first := filepath.Join(dir, "part-000")
+		assert.NoError(t, os.WriteFile(first, []byte("0123456789"), 0o600))
+		missing := filepath.Join(dir, "part-001")
+
+		cmd = exec.Command(binPath, "-quiet", "-from", first, "-from", missing, "-from", first, "-block-size", "4")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Equal(t, "0123456789", stdout.String())
+		assert.Contains(t, stderr.String(), "can not open "+missing)
+		assert.Contains(t, stderr.String(), "after 10 bytes written")
+	})
+
+	t.Run("ok, -from pattern expands in lexical order", func(t *testing.T) {
+		dir := t.TempDir()
+		for _, name := range []string{"part-002.bin", "part-000.bin", "part-001.bin", "other.bin"} {
+			assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(name[:8]+"\n"), 0o600))
+		}
+
+		cmd = exec.Command(binPath, "-quiet", "-from", filepath.Join(dir, "part-*.bin"))
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "part-000\npart-001\npart-002\n", stdout.String())
+	})
+
+	t.Run("fail, -from pattern matches nothing", func(t *testing.T) {
+		pattern := filepath.Join(t.TempDir(), "part-*.bin")
+		cmd = exec.Command(binPath, "-quiet", "-from", pattern)
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "-from pattern \""+pattern+"\" matches no files")
+	})
+
+	t.Run("ok, -no-glob opens a name with metacharacters literally", func(t *testing.T) {
+		dir := t.TempDir()
+		literal := filepath.Join(dir, "part-[1].bin")
+		assert.NoError(t, os.WriteFile(literal, []byte("literal"), 0o600))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "part-1.bin"), []byte("glob"), 0o600))
+
+		cmd = exec.Command(binPath, "-quiet", "-no-glob", "-from", literal)
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "literal", stdout.String())
+	})
+
+	t.Run("ok, -to directory with a trailing slash keeps the source name", func(t *testing.T) {
+		dir := t.TempDir()
+		inputFile := filepath.Join(dir, "in.txt")
+		outputDir := filepath.Join(dir, "out")
+		assert.NoError(t, os.WriteFile(inputFile, []byte("abc"), 0o600))
+		assert.NoError(t, os.Mkdir(outputDir, 0o755))
+
+		cmd = exec.Command(binPath, "-quiet", "-from", inputFile, "-to", outputDir+string(filepath.Separator), "-conv", "upper_case")
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		data, err := os.ReadFile(filepath.Join(outputDir, "in.txt"))
+		assert.NoError(t, err)
+		assert.Equal(t, "ABC", string(data))
+	})
+
+	t.Run("ok, several -from into a symlink to a directory", func(t *testing.T) {
+		dir := t.TempDir()
+		outputDir := filepath.Join(dir, "out")
+		link := filepath.Join(dir, "link")
+		assert.NoError(t, os.Mkdir(outputDir, 0o755))
+		assert.NoError(t, os.Symlink(outputDir, link))
+		args := []string{"-quiet", "-to", link}
+		for _, name := range []string{"a.txt", "b.txt"} {
+			inputFile := filepath.Join(dir, name)
+			assert.NoError(t, os.WriteFile(inputFile, []byte(name), 0o600))
+			args = append(args, "-from", inputFile)
+		}
+
+		cmd = exec.Command(binPath, args...)
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		for _, name := range []string{"a.txt", "b.txt"} {
+			data, err := os.ReadFile(filepath.Join(outputDir, name))
+			assert.NoError(t, err)
+			assert.Equal(t, name, string(data))
+		}
+	})
+
+	t.Run("ok, recursive copy reproduces the tree and skips symlinks", func(t *testing.T) {
+		dir := t.TempDir()
+		source := filepath.Join(dir, "src")
+		target := filepath.Join(dir, "dst")
+		assert.NoError(t, os.MkdirAll(filepath.Join(source, "nested", "deeper"), 0o755))
+		assert.NoError(t, os.Mkdir(filepath.Join(source, "empty"), 0o755))
+		assert.NoError(t, os.WriteFile(filepath.Join(source, "top.txt"), []byte("top"), 0o600))
+		assert.NoError(t, os.WriteFile(filepath.Join(source, "nested", "deeper", "leaf.txt"), []byte("leaf"), 0o600))
+		assert.NoError(t, os.Symlink("top.txt", filepath.Join(source, "link.txt")))
+
+		cmd = exec.Command(binPath, "-recursive", "-from", source, "-to", target, "-conv", "upper_case")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		data, err := os.ReadFile(filepath.Join(target, "top.txt"))
+		assert.NoError(t, err)
+		assert.Equal(t, "TOP", string(data))
+		data, err = os.ReadFile(filepath.Join(target, "nested", "deeper", "leaf.txt"))
+		assert.NoError(t, err)
+		assert.Equal(t, "LEAF", string(data))
+		assert.DirExists(t, filepath.Join(target, "empty"))
+		assert.NoFileExists(t, filepath.Join(target, "link.txt"))
+		assert.Contains(t, stderr.String(), "warning: skipping symlink "+filepath.Join(source, "link.txt"))
+		assert.Contains(t, stderr.String(), "2 files copied, 1 skipped, 0 failed\n")
+	})
+
+	t.Run("fail, recursive copy reports failed files and goes on", func(t *testing.T) {
+		dir := t.TempDir()
+		source := filepath.Join(dir, "src")
+		target := filepath.Join(dir, "dst")
+		assert.NoError(t, os.MkdirAll(source, 0o755))
+		assert.NoError(t, os.MkdirAll(target, 0o755))
+		for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+			assert.NoError(t, os.WriteFile(filepath.Join(source, name), []byte(name), 0o600))
+		}
+		assert.NoError(t, os.WriteFile(filepath.Join(target, "a.txt"), []byte("old"), 0o600))
+
+		cmd = exec.Command(binPath, "-recursive", "-from", source, "-to", target)
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "destination already exists")
+		assert.Contains(t, stderr.String(), "2 files copied, 0 skipped, 1 failed\n")
+		assert.FileExists(t, filepath.Join(target, "c.txt"))
+
+		cmd = exec.Command(binPath, "-recursive", "-fail-fast", "-from", source, "-to", filepath.Join(dir, "fast"))
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, "fast"), 0o755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "fast", "a.txt"), []byte("old"), 0o600))
+		stderr.Reset()
+		cmd.Stderr = stderr
+
+		err = cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "0 files copied, 0 skipped, 1 failed\n")
+		assert.NoFileExists(t, filepath.Join(dir, "fast", "b.txt"))
+	})
+
+	t.Run("ok, -symlinks copy recreates links without conv", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "target.txt"), []byte("abc"), 0o600))
+		assert.NoError(t, os.Symlink("target.txt", filepath.Join(dir, "relative")))
+		assert.NoError(t, os.Symlink(filepath.Join(dir, "missing"), filepath.Join(dir, "dangling")))
+
+		for _, name := range []string{"relative", "dangling"} {
+			cmd = exec.Command(binPath, "-quiet", "-symlinks", "copy", "-conv", "upper_case",
+				"-from", filepath.Join(dir, name), "-to", filepath.Join(dir, name+"-copy"))
+
+			assert.NoError(t, cmd.Run())
+			source, err := os.Readlink(filepath.Join(dir, name))
+			assert.NoError(t, err)
+			copied, err := os.Readlink(filepath.Join(dir, name+"-copy"))
+			assert.NoError(t, err)
+			assert.Equal(t, source, copied)
+		}
+	})
+
+	t.Run("ok, -symlinks skip and follow for a single file", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "target.txt"), []byte("abc"), 0o600))
+		link := filepath.Join(dir, "link")
+		assert.NoError(t, os.Symlink("target.txt", link))
+
+		cmd = exec.Command(binPath, "-symlinks", "skip", "-from", link, "-to", filepath.Join(dir, "skipped"))
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.NoError(t, cmd.Run())
+		assert.Equal(t, "warning: skipping symlink "+link+"\n", stderr.String())
+		assert.NoFileExists(t, filepath.Join(dir, "skipped"))
+
+		cmd = exec.Command(binPath, "-quiet", "-symlinks", "follow", "-from", link, "-to", filepath.Join(dir, "followed"))
+
+		assert.NoError(t, cmd.Run())
+		data, err := os.ReadFile(filepath.Join(dir, "followed"))
+		assert.NoError(t, err)
+		assert.Equal(t, "abc", string(data))
+	})
+
+	t.Run("ok, recursive copy with -symlinks copy", func(t *testing.T) {
+		dir := t.TempDir()
+		source := filepath.Join(dir, "src")
+		target := filepath.Join(dir, "dst")
+		assert.NoError(t, os.Mkdir(source, 0o755))
+		assert.NoError(t, os.WriteFile(filepath.Join(source, "file.txt"), []byte("abc"), 0o600))
+		assert.NoError(t, os.Symlink("file.txt", filepath.Join(source, "link.txt")))
+
+		cmd = exec.Command(binPath, "-recursive", "-symlinks", "copy", "-from", source, "-to", target)
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.NoError(t, cmd.Run())
+		link, err := os.Readlink(filepath.Join(target, "link.txt"))
+		assert.NoError(t, err)
+		assert.Equal(t, "file.txt", link)
+		assert.Contains(t, stderr.String(), "2 files copied, 0 skipped, 0 failed\n")
+	})
+
+	t.Run("fail, unknown -symlinks policy", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-symlinks", "dereference")
+		cmd.Stdin = strings.NewReader("abc")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "unknown -symlinks \"dereference\", supported: follow, skip, copy")
+	})
+
+	t.Run("ok, -preserve mode and times", func(t *testing.T) {
+		dir := t.TempDir()
+		inputFile := filepath.Join(dir, "in")
+		outputFile := filepath.Join(dir, "out")
+		assert.NoError(t, os.WriteFile(inputFile, []byte("abc"), 0o640))
+		assert.NoError(t, os.Chmod(inputFile, 0o640))
+		modified := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		assert.NoError(t, os.Chtimes(inputFile, modified, modified))
+
+		cmd = exec.Command(binPath, "-quiet", "-preserve", "mode,times", "-from", inputFile, "-to", outputFile, "-conv", "upper_case")
+
+		assert.NoError(t, cmd.Run())
+		stat, err := os.Stat(outputFile)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o640), stat.Mode().Perm())
+		assert.True(t, modified.Equal(stat.ModTime()))
+	})
+
+	t.Run("ok, -preserve into stdout is ignored with a warning", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-preserve", "times")
+		cmd.Stdin = strings.NewReader("abc")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.NoError(t, cmd.Run())
+		assert.Equal(t, "abc", stdout.String())
+		assert.Contains(t, stderr.String(), "warning: -preserve needs a single -from file and a -to file, ignored\n")
+	})
+
+	t.Run("fail, unknown -preserve attribute", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-preserve", "mode,owner")
+		cmd.Stdin = strings.NewReader("abc")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "unknown -preserve \"owner\", supported: mode, times")
+	})
+
+	t.Run("ok, -atomic replaces the destination keeping its mode", func(t *testing.T) {
+		dir := t.TempDir()
+		outputFile := filepath.Join(dir, "out")
+		assert.NoError(t, os.WriteFile(outputFile, []byte("old content"), 0o640))
+		assert.NoError(t, os.Chmod(outputFile, 0o640))
+
+		cmd = exec.Command(binPath, "-quiet", "-atomic", "-force", "-to", outputFile)
+		cmd.Stdin = strings.NewReader("new")
+
+		assert.NoError(t, cmd.Run())
+		data, err := os.ReadFile(outputFile)
+		assert.NoError(t, err)
+		assert.Equal(t, "new", string(data))
+		stat, err := os.Stat(outputFile)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o640), stat.Mode().Perm())
+		entries, err := os.ReadDir(dir)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+
+	t.Run("fail, -atomic leaves nothing behind when the copy fails", func(t *testing.T) {
+		dir := t.TempDir()
+		outputFile := filepath.Join(dir, "out")
+
+		cmd = exec.Command(binPath, "-quiet", "-atomic", "-to", outputFile, "-conv", "base64_decode", "-block-size", "4")
+		cmd.Stdin = strings.NewReader("YWJj!!!!")
+
+		assert.Error(t, cmd.Run())
+		entries, err := os.ReadDir(dir)
+		assert.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("fail, -atomic together with -append", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-atomic", "-append", "-to", filepath.Join(t.TempDir(), "out"))
+		cmd.Stdin = strings.NewReader("abc")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "-atomic cannot be used with -append, -seek or -seek-blocks")
+	})
+
+	t.Run("ok, -fsync syncs files and the directory of -atomic", func(t *testing.T) {
+		dir := t.TempDir()
+		for _, args := range [][]string{{"-to", filepath.Join(dir, "plain")}, {"-atomic", "-to", filepath.Join(dir, "atomic")}} {
+			cmd = exec.Command(binPath, append([]string{"-verbose", "-fsync"}, args...)...)
+			cmd.Stdin = strings.NewReader("abc")
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			assert.NoError(t, cmd.Run())
+			if args[0] == "-atomic" {
+				assert.Contains(t, stderr.String(), "synced directory "+dir+"\n")
+			} else {
+				assert.Contains(t, stderr.String(), "synced "+args[1]+"\n")
+			}
+		}
+	})
+
+	t.Run("ok, -fsync is ignored for stdout", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-verbose", "-fsync")
+		cmd.Stdin = strings.NewReader("abc")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.NoError(t, cmd.Run())
+		assert.Equal(t, "abc", stdout.String())
+		assert.Contains(t, stderr.String(), "-fsync ignored, the output is not a file\n")
+	})
+
+	t.Run("ok, -direct with an unaligned offset and tail", func(t *testing.T) {
+		if runtime.GOOS != "linux" {
+			t.Skip("O_DIRECT is linux only")
+		}
+		dir := t.TempDir()
+		inputFile := filepath.Join(dir, "in")
+		outputFile := filepath.Join(dir, "out")
+		input := []byte(strings.Repeat("0123456789abcdef", 1500) + "tail")
+		assert.NoError(t, os.WriteFile(inputFile, input, 0o600))
+
+		cmd = exec.Command(binPath, "-quiet", "-direct", "-from", inputFile, "-to", outputFile, "-offset", "777", "-block-size", "8192")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+		if strings.Contains(stderr.String(), "invalid argument") {
+			t.Skip("O_DIRECT is not supported by the filesystem of the temporary directory")
+		}
+
+		assert.NoError(t, err)
+		data, err := os.ReadFile(outputFile)
+		assert.NoError(t, err)
+		assert.Equal(t, input[777:], data)
+	})
+
+	t.Run("fail, -direct with an unaligned block size", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-direct", "-block-size", "1000")
+		cmd.Stdin = strings.NewReader("abc")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		if runtime.GOOS == "linux" {
+			assert.Contains(t, stderr.String(), "-direct requires -block-size to be a multiple of the logical sector size 4096")
+		} else {
+			assert.Contains(t, stderr.String(), "-direct is not supported")
+		}
+	})
+
+	t.Run("ok, -sparse leaves holes for zero blocks including the tail", func(t *testing.T) {
+		outputFile := filepath.Join(t.TempDir(), "out")
+		input := strings.Repeat("a", 4096) + strings.Repeat("\x00", 8192) + "b" + strings.Repeat("\x00", 8190)
+
+		cmd = exec.Command(binPath, "-sparse", "-to", outputFile, "-block-size", "4096")
+		cmd.Stdin = strings.NewReader(input)
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.NoError(t, cmd.Run())
+		data, err := os.ReadFile(outputFile)
+		assert.NoError(t, err)
+		assert.Equal(t, input, string(data))
+		assert.Contains(t, stderr.String(), ", 12287 bytes skipped as holes\n")
+	})
+
+	t.Run("fail, -sparse together with -append", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-sparse", "-append", "-to", filepath.Join(t.TempDir(), "out"))
+		cmd.Stdin = strings.NewReader("abc")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "-sparse cannot be used with -append, -seek, -seek-blocks or -direct")
+	})
+
+	t.Run("ok, -skip-identical compares sha256 and size", func(t *testing.T) {
+		dir := t.TempDir()
+		inputFile := filepath.Join(dir, "in")
+		outputFile := filepath.Join(dir, "out")
+		assert.NoError(t, os.WriteFile(inputFile, []byte("abc"), 0o600))
+		assert.NoError(t, os.WriteFile(outputFile, []byte("abc"), 0o600))
+
+		cmd = exec.Command(binPath, "-verbose", "-skip-identical", "-from", inputFile, "-to", outputFile)
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.NoError(t, cmd.Run())
+		assert.Contains(t, stderr.String(), "-skip-identical: "+outputFile+" has the same sha256 as "+inputFile+", skipping\n")
+
+		assert.NoError(t, os.WriteFile(outputFile, []byte("xyz"), 0o600))
+		cmd = exec.Command(binPath, "-quiet", "-skip-identical", "-from", inputFile, "-to", outputFile)
+		stderr.Reset()
+		cmd.Stderr = stderr
+
+		assert.Error(t, cmd.Run())
+		assert.Contains(t, stderr.String(), "destination already exists")
+
+		cmd = exec.Command(binPath, "-quiet", "-skip-identical=size", "-from", inputFile, "-to", outputFile)
+
+		assert.NoError(t, cmd.Run())
+		data, err := os.ReadFile(outputFile)
+		assert.NoError(t, err)
+		assert.Equal(t, "xyz", string(data))
+
+		cmd = exec.Command(binPath, "-quiet", "-skip-identical", "-force", "-from", inputFile, "-to", outputFile)
+
+		assert.NoError(t, cmd.Run())
+		data, err = os.ReadFile(outputFile)
+		assert.NoError(t, err)
+		assert.Equal(t, "abc", string(data))
+	})
+
+	t.Run("fail, -skip-identical with -conv", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-skip-identical", "-conv", "upper_case")
+		cmd.Stdin = strings.NewReader("abc")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "-skip-identical compares whole files")
+	})
+
+	t.Run("ok, -resume continues from the size of the destination", func(t *testing.T) {
+		dir := t.TempDir()
+		inputFile := filepath.Join(dir, "in")
+		outputFile := filepath.Join(dir, "out")
+		assert.NoError(t, os.WriteFile(inputFile, []byte("0123456789"), 0o600))
+		assert.NoError(t, os.WriteFile(outputFile, []byte("2345"), 0o600))
+
+		cmd = exec.Command(binPath, "-resume", "-from", inputFile, "-to", outputFile, "-offset", "2")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.NoError(t, cmd.Run())
+		assert.True(t, strings.HasPrefix(stderr.String(), "resumed at byte 4\n4 bytes read, 4 bytes written, "))
+		data, err := os.ReadFile(outputFile)
+		assert.NoError(t, err)
+		assert.Equal(t, "23456789", string(data))
+	})
+
+	t.Run("fail, -resume with a larger destination or -conv", func(t *testing.T) {
+		dir := t.TempDir()
+		inputFile := filepath.Join(dir, "in")
+		outputFile := filepath.Join(dir, "out")
+		assert.NoError(t, os.WriteFile(inputFile, []byte("0123"), 0o600))
+		assert.NoError(t, os.WriteFile(outputFile, []byte("012345"), 0o600))
+
+		cmd = exec.Command(binPath, "-quiet", "-resume", "-from", inputFile, "-to", outputFile)
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.Error(t, cmd.Run())
+		assert.Contains(t, stderr.String(), "has 6 bytes, more than 4 bytes to copy")
+
+		cmd = exec.Command(binPath, "-quiet", "-resume", "-from", inputFile, "-to", outputFile, "-conv", "upper_case")
+		stderr.Reset()
+		cmd.Stderr = stderr
+
+		assert.Error(t, cmd.Run())
+		assert.Contains(t, stderr.String(), "-resume cannot be used with -conv")
+	})
+
+	t.Run("ok, -hash digests the converted bytes in one pass", func(t *testing.T) {
+		outputFile := filepath.Join(t.TempDir(), "out")
+		cmd = exec.Command(binPath, "-quiet", "-hash", "sha256,md5", "-hash-to-stdout", "-to", outputFile, "-conv", "upper_case")
+		cmd.Stdin = strings.NewReader("abc")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+
+		assert.NoError(t, cmd.Run())
+		sha := sha256.Sum256([]byte("ABC"))
+		sum := md5.Sum([]byte("ABC"))
+		assert.Equal(t, hex.EncodeToString(sha[:])+"  "+outputFile+"\n"+hex.EncodeToString(sum[:])+"  "+outputFile+"\n", stdout.String())
+	})
+
+	t.Run("ok, -hash of stdout goes to stderr", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-hash", "sha1")
+		cmd.Stdin = strings.NewReader("abc")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.NoError(t, cmd.Run())
+		sum := sha1.Sum([]byte("abc"))
+		assert.Equal(t, "abc", stdout.String())
+		assert.Equal(t, hex.EncodeToString(sum[:])+"  -\n", stderr.String())
+	})
+
+	t.Run("fail, unknown -hash", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-hash", "sha256,crc32")
+		cmd.Stdin = strings.NewReader("abc")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.Error(t, cmd.Run())
+		assert.Contains(t, stderr.String(), "unknown -hash \"crc32\"")
+	})
+
+	t.Run("ok, -verify reads the destination back", func(t *testing.T) {
+		dir := t.TempDir()
+		inputFile := filepath.Join(dir, "in")
+		outputFile := filepath.Join(dir, "out")
+		assert.NoError(t, os.WriteFile(inputFile, []byte("0123456789"), 0o600))
+		assert.NoError(t, os.WriteFile(outputFile, []byte("head"), 0o600))
+
+		cmd = exec.Command(binPath, "-verbose", "-verify", "-append", "-from", inputFile, "-to", outputFile, "-offset", "3", "-block-size", "4")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.NoError(t, cmd.Run())
+		assert.Contains(t, stderr.String(), "verified 7 bytes of "+outputFile+" by sha256\n")
+		assert.Contains(t, stderr.String(), "compared 7 bytes of "+outputFile+" with "+inputFile+"\n")
+
+		cmd = exec.Command(binPath, "-verbose", "-verify", "-force", "-from", inputFile, "-to", outputFile, "-conv", "hex_encode")
+		stderr.Reset()
+		cmd.Stderr = stderr
+
+		assert.NoError(t, cmd.Run())
+		assert.Contains(t, stderr.String(), "verified 20 bytes of "+outputFile+" by sha256\n")
+		assert.NotContains(t, stderr.String(), "compared")
+	})
+
+	t.Run("fail, -verify into stdout", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-verify")
+		cmd.Stdin = strings.NewReader("abc")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.Error(t, cmd.Run())
+		assert.Contains(t, stderr.String(), "-verify requires -to")
+	})
+
+	t.Run("ok, several -to including stdout", func(t *testing.T) {
+		dir := t.TempDir()
+		first := filepath.Join(dir, "first")
+		second := filepath.Join(dir, "second")
+
+		cmd = exec.Command(binPath, "-to", first, "-to", "-", "-to", second, "-conv", "upper_case")
+		cmd.Stdin = strings.NewReader("abc")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.NoError(t, cmd.Run())
+		assert.Equal(t, "ABC", stdout.String())
+		for _, path := range []string{first, second} {
+			data, err := os.ReadFile(path)
+			assert.NoError(t, err)
+			assert.Equal(t, "ABC", string(data))
+		}
+		assert.Contains(t, stderr.String(), "\n3 bytes written to "+first+"\n3 bytes written to stdout\n3 bytes written to "+second+"\n")
+	})
+
+	t.Run("fail, one of several -to fails", func(t *testing.T) {
+		if _, err := os.Stat("/dev/full"); err != nil {
+			t.Skip("no /dev/full to fail writes")
+		}
+		good := filepath.Join(t.TempDir(), "good")
+
+		cmd = exec.Command(binPath, "-quiet", "-force", "-to", good, "-to", "/dev/full")
+		cmd.Stdin = strings.NewReader("abc")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.Error(t, cmd.Run())
+		assert.Contains(t, stderr.String(), "destination /dev/full failed after 0 bytes")
+
+		assert.NoError(t, os.Remove(good))
+		cmd = exec.Command(binPath, "-tee-best-effort", "-force", "-to", "/dev/full", "-to", good)
+		cmd.Stdin = strings.NewReader("abc")
+		stderr.Reset()
+		cmd.Stderr = stderr
+
+		assert.NoError(t, cmd.Run())
+		assert.Contains(t, stderr.String(), "destination /dev/full failed after 0 bytes")
+		assert.Contains(t, stderr.String(), "\n0 bytes written to /dev/full (failed)\n3 bytes written to "+good+"\n")
+		data, err := os.ReadFile(good)
+		assert.NoError(t, err)
+		assert.Equal(t, "abc", string(data))
+	})
+
+	t.Run("ok, -max-rate throttles reading after one block of burst", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-max-rate", "10kB", "-block-size", "1000")
+		cmd.Stdin = strings.NewReader(strings.Repeat("a", 3000))
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		started := time.Now()
+		assert.NoError(t, cmd.Run())
+		assert.GreaterOrEqual(t, time.Since(started), 150*time.Millisecond)
+		assert.Equal(t, 3000, stdout.Len())
+		assert.Contains(t, stderr.String(), "/s of -max-rate 9.8 KiB/s\n")
+	})
+
+	t.Run("error, -timeout aborts a stalled copy with its own exit code", func(t *testing.T) {
+		dir := t.TempDir()
+		for _, atomic := range []bool{false, true} {
+			to := filepath.Join(dir, "out-"+strconv.FormatBool(atomic))
+			cmd = exec.Command(binPath, "-timeout", "200ms", "-block-size", "4", "-to", to, "-atomic="+strconv.FormatBool(atomic))
+			stdin, err := cmd.StdinPipe()
+			assert.NoError(t, err)
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+			assert.NoError(t, cmd.Start())
+			_, err = stdin.Write([]byte("0123"))
+			assert.NoError(t, err)
+
+			err = cmd.Wait()
+			_ = stdin.Close()
+
+			var exitErr *exec.ExitError
+			assert.ErrorAs(t, err, &exitErr)
+			assert.Equal(t, 124, exitErr.ExitCode())
+			assert.Contains(t, stderr.String(), "timed out after 200ms")
+			data, err := os.ReadFile(to)
+			if atomic {
+				assert.ErrorIs(t, err, os.ErrNotExist)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "0123", string(data))
+			}
+		}
+		entries, err := os.ReadDir(dir)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+
+	t.Run("ok, -retries keeps seeking over -offset and reads directories once", func(t *testing.T) {
+		inputFile := filepath.Join(t.TempDir(), "in")
+		assert.NoError(t, os.WriteFile(inputFile, []byte("0123456789"), 0o600))
+		cmd = exec.Command(binPath, "-quiet", "-retries", "3", "-offset", "5", "-limit", "4", "-from", inputFile)
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+
+		assert.NoError(t, cmd.Run())
+		assert.Equal(t, "5678", stdout.String())
+
+		cmd = exec.Command(binPath, "-verbose", "-retries", "3", "-retry-delay", "10ms", "-from", t.TempDir())
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.Error(t, cmd.Run())
+		assert.Contains(t, stderr.String(), "is a directory")
+		assert.NotContains(t, stderr.String(), "retry 1 of 3")
+	})
+
+	t.Run("error, -retries with several -from", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-retries", "1", "-from", "a", "-from", "b")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.Error(t, cmd.Run())
+		assert.Contains(t, stderr.String(), "-retries cannot be used with several -from or -direct")
+	})
+
+	t.Run("ok, noerror with sync replaces unreadable blocks with zeros", func(t *testing.T) {
+		if runtime.GOOS != "linux" {
+			t.Skip("reading unmapped /proc/self/mem fails with EIO only on linux")
+		}
+		cmd = exec.Command(binPath, "-from", "/proc/self/mem", "-conv", "noerror,sync", "-block-size", "1K", "-count", "2")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.NoError(t, cmd.Run())
+		assert.Equal(t, strings.Repeat("\x00", 2048), stdout.String())
+		assert.Contains(t, stderr.String(), "read error at byte 1024: read /proc/self/mem: input/output error\n")
+		assert.Contains(t, stderr.String(), ", 2 unreadable blocks at 0, 1024\n")
+
+		errorMap := filepath.Join(t.TempDir(), "errors")
+		cmd = exec.Command(binPath, "-quiet", "-from", "/proc/self/mem", "-conv", "noerror,sync",
+			"-block-size", "1K", "-count", "2", "-error-map", errorMap)
+
+		assert.NoError(t, cmd.Run())
+		data, err := os.ReadFile(errorMap)
+		assert.NoError(t, err)
+		assert.Equal(t, "0 1024\n1024 1024\n", string(data))
+
+		cmd = exec.Command(binPath, "-from", "/proc/self/mem", "-block-size", "1K", "-count", "2")
+		stderr.Reset()
+		cmd.Stderr = stderr
+
+		assert.Error(t, cmd.Run())
+		assert.Contains(t, stderr.String(), "error while copping: read /proc/self/mem: input/output error")
+	})
+
+	t.Run("error, -error-map without noerror", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-error-map", "errors")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.Error(t, cmd.Run())
+		assert.Contains(t, stderr.String(), "-error-map requires -conv noerror")
+	})
+
+	t.Run("ok, -iflag fullblock fills blocks from a dribbling stdin", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"-iflag", "fullblock", "-block-size", "4", "-count", "2", "-conv", "sync"},
+			{"iflag=fullblock", "bs=4", "count=2", "conv=sync"},
+		} {
+			cmd = exec.Command(binPath, args...)
+			cmd.Stdin = &dribbleReader{data: []byte("0123456789")}
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			assert.NoError(t, cmd.Run())
+			assert.Equal(t, "01234567", stdout.String())
+			assert.Contains(t, stderr.String(), "2+0 records in\n2+0 records out\n")
+		}
+	})
+
+	t.Run("error, -offset beyond the end of stdin", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-offset", "20")
+		cmd.Stdin = &dribbleReader{data: []byte("0123456789")}
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.Error(t, cmd.Run())
+		assert.Contains(t, stderr.String(), "can not skip 20 bytes of -offset: input ended after 10")
+	})
+
+	t.Run("error, -offset beyond the end of a file", func(t *testing.T) {
+		inputFile := filepath.Join(t.TempDir(), "in")
+		assert.NoError(t, os.WriteFile(inputFile, []byte("0123456789"), 0o600))
+
+		cmd = exec.Command(binPath, "-from", inputFile, "-offset", "20")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		var exitErr *exec.ExitError
+		if assert.ErrorAs(t, err, &exitErr) {
+			assert.Equal(t, 2, exitErr.ExitCode())
+		}
+		assert.Contains(t, stderr.String(), "can not skip 20 bytes of -offset: input ended after 10")
+	})
+
+	t.Run("error, unknown -iflag", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-iflag", "direct")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.Error(t, cmd.Run())
+		assert.Contains(t, stderr.String(), "unknown -iflag direct, supported: fullblock")
+	})
+
+	t.Run("ok, flags take defaults from the environment", func(t *testing.T) {
+		for _, tc := range []struct {
+			args     []string
+			expected string
+		}{
+			{nil, "ABC"},
+			{[]string{"-conv", "lower_case"}, "abc"},
+			{[]string{"conv=lower_case"}, "abc"},
+			{[]string{"-ignore-env"}, "aBc"},
+		} {
+			cmd = exec.Command(binPath, append([]string{"-quiet"}, tc.args...)...)
+			cmd.Env = append(os.Environ(), "CFU_CONV=upper_case", "CFU_BLOCK_SIZE=2")
+			cmd.Stdin = strings.NewReader("aBc")
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+
+			assert.NoError(t, cmd.Run(), tc.args)
+			assert.Equal(t, tc.expected, stdout.String(), tc.args)
+		}
+	})
+
+	t.Run("error, invalid environment defaults name the variable", func(t *testing.T) {
+		for _, tc := range []struct {
+			env      string
+			expected string
+		}{
+			{"CFU_BLOCK_SIZE=x", `invalid value "x" of environment variable CFU_BLOCK_SIZE for -block-size`},
+			{"CFU_BLOCK_SIZE=0", "-block-size must be positive (-block-size is taken from environment variable CFU_BLOCK_SIZE)"},
+			{"CFU_CONV=nope", "unknown conv nope (-conv is taken from environment variable CFU_CONV)"},
+		} {
+			cmd = exec.Command(binPath)
+			cmd.Env = append(os.Environ(), tc.env)
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			assert.Error(t, cmd.Run(), tc.env)
+			assert.Contains(t, stderr.String(), tc.expected, tc.env)
+		}
+	})
+
+	t.Run("ok, -config is overridden by flags and overrides the environment", func(t *testing.T) {
+		dir := t.TempDir()
+		yamlConfig := filepath.Join(dir, "job.yaml")
+		assert.NoError(t, os.WriteFile(yamlConfig, []byte("block_size: 2\nconv: [upper_case, trim_spaces]\nlimit: 4\n"), 0o600))
+		jsonConfig := filepath.Join(dir, "job.json")
+		assert.NoError(t, os.WriteFile(jsonConfig, []byte(`{"conv": "lower_case", "hash": ["md5", "sha1"]}`), 0o600))
+
+		for _, tc := range []struct {
+			args     []string
+			env      string
+			expected string
+		}{
+			{[]string{"-config", yamlConfig}, "", "ABC"},
+			{[]string{"-config", yamlConfig, "-limit", "6"}, "", "ABCDE"},
+			{[]string{"-config", yamlConfig, "conv=swap_case"}, "", " AbC"},
+			{[]string{"-config", yamlConfig}, "CFU_LIMIT=2", "ABC"},
+			{[]string{"-config", jsonConfig}, "CFU_LIMIT=2", " a"},
+		} {
+			cmd = exec.Command(binPath, append([]string{"-quiet"}, tc.args...)...)
+			cmd.Env = append(os.Environ(), tc.env)
+			cmd.Stdin = strings.NewReader(" aBcdE")
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+
+			assert.NoError(t, cmd.Run(), tc.args)
+			assert.Equal(t, tc.expected, stdout.String(), tc.args)
+		}
+
+		cmd = exec.Command(binPath, "-config", jsonConfig, "-hash-to-stdout", "-to", filepath.Join(dir, "out"))
+		cmd.Stdin = strings.NewReader("abc")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+
+		assert.NoError(t, cmd.Run())
+		assert.Equal(t, "900150983cd24fb0d6963f7d28e17f72  "+filepath.Join(dir, "out")+"\n"+
+			"a9993e364706816aba3e25717850c26c9cd0d89d  "+filepath.Join(dir, "out")+"\n", stdout.String())
+	})
+
+	t.Run("error, invalid -config", func(t *testing.T) {
+		dir := t.TempDir()
+		for _, tc := range []struct {
+			config   string
+			expected string
+		}{
+			{"nope: 1\n", `unknown key "nope" in -config `},
+			{"block_size: 0\n", "-block-size must be positive (-block-size is taken from key block_size of -config "},
+			{"conv: [upper_case, nope]\n", "unknown conv nope (-conv is taken from key conv of -config "},
+			{"conv:\n  upper: true\n", `key "conv" in -config `},
+			{"block_size: [", "can not parse -config "},
+		} {
+			config := filepath.Join(dir, "job.yaml")
+			assert.NoError(t, os.WriteFile(config, []byte(tc.config), 0o600))
+			cmd = exec.Command(binPath, "-config", config)
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			assert.Error(t, cmd.Run(), tc.config)
+			assert.Contains(t, stderr.String(), tc.expected, tc.config)
+		}
+	})
+
+	t.Run("ok, positional source and destination", func(t *testing.T) {
+		dir := t.TempDir()
+		inputFile := filepath.Join(dir, "in")
+		assert.NoError(t, os.WriteFile(inputFile, []byte("0123456789"), 0o600))
+		outputFile := filepath.Join(dir, "out")
+
+		cmd = exec.Command(binPath, "-quiet", inputFile, outputFile, "-limit", "4")
+
+		assert.NoError(t, cmd.Run())
+		data, err := os.ReadFile(outputFile)
+		assert.NoError(t, err)
+		assert.Equal(t, "0123", string(data))
+
+		cmd = exec.Command(binPath, "-quiet", "-", "-")
+		cmd.Stdin = strings.NewReader("abc")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+
+		assert.NoError(t, cmd.Run())
+		assert.Equal(t, "abc", stdout.String())
+
+		outputDir := filepath.Join(dir, "copies")
+		assert.NoError(t, os.Mkdir(outputDir, 0o755))
+		cmd = exec.Command(binPath, "-quiet", inputFile, outputFile, outputDir)
+
+		assert.NoError(t, cmd.Run())
+		for name, expected := range map[string]string{"in": "0123456789", "out": "0123"} {
+			data, err = os.ReadFile(filepath.Join(outputDir, name))
+			assert.NoError(t, err)
+			assert.Equal(t, expected, string(data))
+		}
+	})
+
+	t.Run("error, ambiguous positional arguments", func(t *testing.T) {
+		for _, tc := range []struct {
+			args     []string
+			expected string
+		}{
+			{[]string{"-from", "a", "b"}, "source given both as -from and as argument b"},
+			{[]string{"-to", "c", "a", "b"}, "destination given both as -to and as argument b"},
+			{[]string{"of=c", "a", "b"}, "destination given both as -to and as argument b"},
+			{[]string{"a", "b", "c"}, "with more than two arguments the last one must be a directory, got c"},
+			{[]string{"a", "-", t.TempDir()}, "- (stdin) must be the only source"},
+		} {
+			cmd = exec.Command(binPath, tc.args...)
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			assert.Error(t, cmd.Run(), tc.args)
+			assert.Contains(t, stderr.String(), tc.expected, tc.args)
+		}
+	})
+
+	t.Run("ok, usage shows flags and positional arguments", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-h")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.NoError(t, cmd.Run())
+		assert.Contains(t, stderr.String(), " [flags] [SRC [DST]]\n")
+		assert.Contains(t, stderr.String(), " [flags] SRC... DIR\n")
+		assert.Contains(t, stderr.String(), " [flags] [-from SRC] [-to DST]\n")
+	})
+
+	t.Run("error, exit codes tell the failure class", func(t *testing.T) {
+		dir := t.TempDir()
+		input := filepath.Join(dir, "in")
+		assert.NoError(t, os.WriteFile(input, []byte("abc"), 0o600))
+		for _, tc := range []struct {
+			args     []string
+			stdin    string
+			expected int
+		}{
+			{[]string{"-bogus"}, "", 1},
+			{[]string{"-block-size", "0"}, "", 1},
+			{[]string{"-from", filepath.Join(dir, "missing")}, "", 2},
+			{[]string{"-from", input, "-from", input, "-from", filepath.Join(dir, "missing"), "-to", filepath.Join(dir, "out")}, "", 2},
+			{[]string{"-to", filepath.Join(dir, "missing", "out")}, "abc", 3},
+			{[]string{"-force", "-to", "/dev/full"}, "abc", 3},
+			{[]string{"-conv", "base64_decode"}, "!!!", 4},
+			{[]string{"-conv", "gunzip"}, "abc", 4},
+			{[]string{"-verify", "-force", "-to", "/dev/null"}, "abc", 5},
+		} {
+			cmd = exec.Command(binPath, tc.args...)
+			cmd.Stdin = strings.NewReader(tc.stdin)
+
+			err := cmd.Run()
+
+			var exitErr *exec.ExitError
+			if assert.ErrorAs(t, err, &exitErr, tc.args) {
+				assert.Equal(t, tc.expected, exitErr.ExitCode(), tc.args)
+			}
+		}
+	})
+
+	t.Run("error, an interrupted copy exits with 130", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet")
+		stdin, err := cmd.StdinPipe()
+		assert.NoError(t, err)
+		stdout, err := cmd.StdoutPipe()
+		assert.NoError(t, err)
+		assert.NoError(t, cmd.Start())
+		_, err = stdin.Write([]byte("abc"))
+		assert.NoError(t, err)
+		copied := make([]byte, 3)
+		_, err = io.ReadFull(stdout, copied)
+		assert.NoError(t, err)
+
+		assert.NoError(t, cmd.Process.Signal(syscall.SIGTERM))
+		err = cmd.Wait()
+		_ = stdin.Close()
+
+		var exitErr *exec.ExitError
+		if assert.ErrorAs(t, err, &exitErr) {
+			assert.Equal(t, 130, exitErr.ExitCode())
+		}
+	})
+
+	t.Run("ok, -json reports the result as a single object", func(t *testing.T) {
+		outputFile := filepath.Join(t.TempDir(), "out")
+		cmd = exec.Command(binPath, "-json", "-conv", "upper_case", "-hash", "md5", "-to", outputFile)
+		cmd.Stdin = strings.NewReader("abc")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.NoError(t, cmd.Run())
+		var result map[string]any
+		assert.NoError(t, json.Unmarshal([]byte(stderr.String()), &result), stderr.String())
+		delete(result, "duration_ms")
+		assert.Equal(t, map[string]any{
+			"source":        "-",
+			"destination":   outputFile,
+			"bytes_read":    float64(3),
+			"bytes_written": float64(3),
+			"conversions":   []any{"upper_case"},
+			"checksums":     map[string]any{"md5": "902fbdd2b1df0c4f70b4a5d23525e932"},
+			"partial":       false,
+		}, result)
+	})
+
+	t.Run("error, -json-file reports a failed copy as partial", func(t *testing.T) {
+		resultFile := filepath.Join(t.TempDir(), "result.json")
+		cmd = exec.Command(binPath, "-json-file", resultFile, "-conv", "base64_decode")
+		cmd.Stdin = strings.NewReader("!!!")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.Error(t, cmd.Run())
+		assert.Empty(t, stderr.String())
+		data, err := os.ReadFile(resultFile)
+		assert.NoError(t, err)
+		var result map[string]any
+		assert.NoError(t, json.Unmarshal(data, &result), string(data))
+		assert.Equal(t, true, result["partial"])
+		assert.Equal(t, "conversion", result["error_class"])
+		assert.Contains(t, result["error"], "error while copping: ")
+		assert.Equal(t, float64(3), result["bytes_read"])
+	})
+
+	t.Run("ok, -log-file appends key=value lines whatever the verbosity", func(t *testing.T) {
+		dir := t.TempDir()
+		logFile := filepath.Join(dir, "copy.log")
+		outputFile := filepath.Join(dir, "out")
+
+		cmd = exec.Command(binPath, "-quiet", "-log-file", logFile, "-to", outputFile)
+		cmd.Stdin = strings.NewReader("abc")
+		assert.NoError(t, cmd.Run())
+		cmd = exec.Command(binPath, "-quiet", "-log-file", logFile, "-from", filepath.Join(dir, "missing"))
+		assert.Error(t, cmd.Run())
+
+		data, err := os.ReadFile(logFile)
+		assert.NoError(t, err)
+		lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		for _, line := range lines {
+			assert.Regexp(t, `^time=\S+ level=(DEBUG|INFO|ERROR) msg=`, line)
+		}
+		log := string(data)
+		assert.Contains(t, log, `level=INFO msg=started args="-quiet -log-file `+logFile+" -to "+outputFile+`"`)
+		assert.Contains(t, log, `level=DEBUG msg="reading from stdin"`)
+		assert.Regexp(t, `level=INFO msg=finished from=- to=\S+ bytes_read=3 bytes_written=3 duration_ms=\d+ skipped=false\n`, log)
+		assert.Regexp(t, `level=ERROR msg=failed from=\S+ to=- bytes_read=0 bytes_written=0 duration_ms=\d+ error=".*" class=source\n`, log)
+	})
+
+	t.Run("ok, -log-file that can not be opened only warns", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-log-file", filepath.Join(t.TempDir(), "missing", "copy.log"))
+		cmd.Stdin = strings.NewReader("abc")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.NoError(t, cmd.Run())
+		assert.Equal(t, "abc", stdout.String())
+		assert.Contains(t, stderr.String(), "warning: can not open -log-file: ")
+	})
+
+	t.Run("ok, -backup renames the existing destination first", func(t *testing.T) {
+		outputFile := filepath.Join(t.TempDir(), "out")
+		assert.NoError(t, os.WriteFile(outputFile, []byte("old"), 0o600))
+
+		cmd = exec.Command(binPath, "-backup", "-backup-suffix", ".bak", "-to", outputFile, "-conv", "base64_decode")
+		cmd.Stdin = strings.NewReader("bmV3!!!!")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.Error(t, cmd.Run())
+		assert.Contains(t, stderr.String(), "backed up "+outputFile+" to "+outputFile+".bak\n")
+		data, err := os.ReadFile(outputFile + ".bak")
+		assert.NoError(t, err)
+		assert.Equal(t, "old", string(data))
+	})
+
+	t.Run("ok, -backup=numbered never overwrites an older backup", func(t *testing.T) {
+		outputFile := filepath.Join(t.TempDir(), "out")
+		assert.NoError(t, os.WriteFile(outputFile, []byte("v0"), 0o600))
+
+		for _, version := range []string{"v1", "v2", "v3"} {
+			cmd = exec.Command(binPath, "-quiet", "-backup=numbered", "-to", outputFile)
+			cmd.Stdin = strings.NewReader(version)
+			assert.NoError(t, cmd.Run())
+		}
+
+		for path, expected := range map[string]string{
+			outputFile:          "v3",
+			outputFile + ".~1~": "v0",
+			outputFile + ".~2~": "v1",
+			outputFile + ".~3~": "v2",
+		} {
+			data, err := os.ReadFile(path)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, string(data), path)
+		}
+	})
+
+	t.Run("ok, -mode sets permissions of a new, overwritten and atomic -to", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("windows has no unix permissions")
+		}
+		dir := t.TempDir()
+		existing := filepath.Join(dir, "existing")
+		assert.NoError(t, os.WriteFile(existing, []byte("old"), 0o644))
+		assert.NoError(t, os.Chmod(existing, 0o644))
+
+		for _, tc := range []struct {
+			args     []string
+			path     string
+			expected os.FileMode
+		}{
+			{[]string{"-mode", "0666"}, filepath.Join(dir, "new"), 0o666},
+			{[]string{"-mode", "600", "-force"}, existing, 0o600},
+			{[]string{"-mode", "0640", "-atomic", "-force"}, existing, 0o640},
+			{[]string{"-mode", "0604", "-append"}, existing, 0o604},
+		} {
+			cmd = exec.Command(binPath, append([]string{"-quiet", "-to", tc.path}, tc.args...)...)
+			cmd.Stdin = strings.NewReader("new")
+
+			assert.NoError(t, cmd.Run(), tc.args)
+			stat, err := os.Stat(tc.path)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, stat.Mode().Perm(), tc.args)
+		}
+		entries, err := os.ReadDir(dir)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 2)
+	})
+
+	t.Run("fail, invalid -mode", func(t *testing.T) {
+		for _, tc := range []struct {
+			args     []string
+			expected string
+		}{
+			{[]string{"-mode", "0689", "-to", "out"}, "-mode must be octal permission bits from 0000 to 0777, got \"0689\""},
+			{[]string{"-mode", "04755", "-to", "out"}, "-mode must be octal permission bits from 0000 to 0777, got \"04755\""},
+			{[]string{"-mode", "0600"}, "-mode requires -to"},
+			{[]string{"-mode", "0600", "-preserve", "mode", "-to", "out"}, "-mode and -preserve mode cannot be used at the same time"},
+		} {
+			cmd = exec.Command(binPath, tc.args...)
+			cmd.Dir = t.TempDir()
+			cmd.Stdin = strings.NewReader("abc")
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			assert.Error(t, cmd.Run())
+			assert.Contains(t, stderr.String(), tc.expected)
+			_, err := os.Stat(filepath.Join(cmd.Dir, "out"))
+			assert.ErrorIs(t, err, os.ErrNotExist)
+		}
+	})
+
+	t.Run("ok, -parents creates missing directories of -to", func(t *testing.T) {
+		dir := t.TempDir()
+		outputFile := filepath.Join(dir, "build", "output", "image.bin")
+
+		cmd = exec.Command(binPath, "-dry-run", "-parents", "-to", outputFile)
+		cmd.Stdin = strings.NewReader("abc")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+
+		assert.NoError(t, cmd.Run())
+		assert.Contains(t, stdout.String(), "destination: "+outputFile+", will be created with its parent directories\n")
+		_, err := os.Stat(filepath.Join(dir, "build"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+
+		cmd = exec.Command(binPath, "-quiet", "-parents", "-dir-mode", "0700", "-to", outputFile)
+		cmd.Stdin = strings.NewReader("abc")
+
+		assert.NoError(t, cmd.Run())
+		data, err := os.ReadFile(outputFile)
+		assert.NoError(t, err)
+		assert.Equal(t, "abc", string(data))
+		if runtime.GOOS != "windows" {
+			stat, err := os.Stat(filepath.Join(dir, "build", "output"))
+			assert.NoError(t, err)
+			assert.Equal(t, os.FileMode(0o700), stat.Mode().Perm())
+		}
+	})
+
+	t.Run("fail, missing parent or a parent that is a file", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "file"), []byte("x"), 0o600))
+
+		for _, tc := range []struct {
+			args     []string
+			expected string
+		}{
+			{
+				[]string{"-to", filepath.Join(dir, "missing", "out")},
+				"can not create " + filepath.Join(dir, "missing", "out") + ": parent directory " + filepath.Join(dir, "missing") + " does not exist, create it or pass -parents",
+			},
+			{
+				[]string{"-parents", "-to", filepath.Join(dir, "file", "sub", "out")},
+				"can not create " + filepath.Join(dir, "file", "sub", "out") + ": " + filepath.Join(dir, "file") + " is not a directory",
+			},
+			{
+				[]string{"-dry-run", "-to", filepath.Join(dir, "file", "out")},
+				"can not create " + filepath.Join(dir, "file", "out") + ": " + filepath.Join(dir, "file") + " is not a directory",
+			},
+			{[]string{"-dir-mode", "0700", "-to", filepath.Join(dir, "out")}, "-dir-mode requires -parents or -recursive"},
+		} {
+			cmd = exec.Command(binPath, tc.args...)
+			cmd.Stdin = strings.NewReader("abc")
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			assert.Error(t, cmd.Run())
+			assert.Contains(t, stderr.String(), tc.expected)
+			assert.NotContains(t, stderr.String(), "no such file or directory")
+		}
+	})
+
+	t.Run("fail, partial -to is kept or removed with -cleanup-on-error", func(t *testing.T) {
+		dir := t.TempDir()
+		outputFile := filepath.Join(dir, "out")
+
+		for _, tc := range []struct {
+			args     []string
+			expected string
+			exists   bool
+		}{
+			{nil, "partial " + outputFile + " kept", true},
+			{[]string{"-cleanup-on-error"}, "partial " + outputFile + " removed", false},
+		} {
+			cmd = exec.Command(binPath, append([]string{"-to", outputFile, "-conv", "base64_decode", "-block-size", "4"}, tc.args...)...)
+			cmd.Stdin = strings.NewReader("YWJj!!!!")
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			assert.Error(t, cmd.Run())
+			assert.Contains(t, stderr.String(), tc.expected)
+			_, err := os.Stat(outputFile)
+			assert.Equal(t, tc.exists, err == nil, tc.args)
+			_ = os.Remove(outputFile)
+		}
+	})
+
+	t.Run("fail, -cleanup-on-error keeps a -to that existed before", func(t *testing.T) {
+		outputFile := filepath.Join(t.TempDir(), "out")
+		for _, args := range [][]string{{"-force"}, {"-append"}} {
+			assert.NoError(t, os.WriteFile(outputFile, []byte("old"), 0o600))
+
+			cmd = exec.Command(binPath, append([]string{"-cleanup-on-error", "-to", outputFile, "-conv", "base64_decode", "-block-size", "4"}, args...)...)
+			cmd.Stdin = strings.NewReader("YWJj!!!!")
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			assert.Error(t, cmd.Run())
+			assert.NotContains(t, stderr.String(), "removed")
+			assert.Contains(t, stderr.String(), "partial output kept at "+outputFile, args)
+			_, err := os.Stat(outputFile)
+			assert.NoError(t, err, args)
+		}
+	})
+
+	t.Run("error, SIGINT stops the copy at a block boundary with a summary", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("signals can not be sent on windows")
+		}
+		outputFile := filepath.Join(t.TempDir(), "out")
+		cmd = exec.Command(binPath, "-from", "/dev/zero", "-to", outputFile, "-block-size", "4096", "-max-rate", "1M")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+		assert.NoError(t, cmd.Start())
+		assert.Eventually(t, func() bool {
+			stat, err := os.Stat(outputFile)
+			return err == nil && stat.Size() > 0
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.NoError(t, cmd.Process.Signal(os.Interrupt))
+
+		err := cmd.Wait()
+
+		var exitErr *exec.ExitError
+		assert.ErrorAs(t, err, &exitErr)
+		assert.Equal(t, 130, exitErr.ExitCode())
+		assert.Contains(t, stderr.String(), "SIGINT received, stopping after the current block, send it again to exit immediately\n")
+		assert.Contains(t, stderr.String(), "interrupted, partial "+outputFile+" kept\n")
+		stat, err := os.Stat(outputFile)
+		assert.NoError(t, err)
+		assert.Zero(t, stat.Size()%4096)
+		assert.Contains(t, stderr.String(), fmt.Sprintf("partial: %d bytes read, %d bytes written", stat.Size(), stat.Size()))
+	})
+
+	t.Run("error, SIGTERM abandons a stalled read and applies -cleanup-on-error", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("signals can not be sent on windows")
+		}
+		outputFile := filepath.Join(t.TempDir(), "out")
+		cmd = exec.Command(binPath, "-cleanup-on-error", "-block-size", "4", "-to", outputFile)
+		stdin, err := cmd.StdinPipe()
+		assert.NoError(t, err)
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+		assert.NoError(t, cmd.Start())
+		_, err = stdin.Write([]byte("0123"))
+		assert.NoError(t, err)
+		assert.Eventually(t, func() bool {
+			stat, err := os.Stat(outputFile)
+			return err == nil && stat.Size() == 4
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.NoError(t, cmd.Process.Signal(syscall.SIGTERM))
+
+		err = cmd.Wait()
+		_ = stdin.Close()
+
+		var exitErr *exec.ExitError
+		assert.ErrorAs(t, err, &exitErr)
+		assert.Equal(t, 130, exitErr.ExitCode())
+		assert.Contains(t, stderr.String(), "interrupted after 4 bytes written, partial "+outputFile+" removed\n")
+		_, err = os.Stat(outputFile)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("fail, -to is -from or a link to it", func(t *testing.T) {
+		dir := t.TempDir()
+		inputFile := filepath.Join(dir, "data.txt")
+		assert.NoError(t, os.WriteFile(inputFile, []byte("precious"), 0o600))
+		symlink, hardlink := filepath.Join(dir, "symlink"), filepath.Join(dir, "hardlink")
+		assert.NoError(t, os.Symlink(inputFile, symlink))
+		assert.NoError(t, os.Link(inputFile, hardlink))
+
+		for _, args := range [][]string{
+			{"-force", "-from", inputFile, "-to", inputFile},
+			{"-force", "-from", inputFile, "-to", symlink},
+			{"-force", "-from", symlink, "-to", hardlink},
+			{"-append", "-from", inputFile, "-to", inputFile},
+			{"-dry-run", "-force", "-from", inputFile, "-to", symlink},
+		} {
+			cmd = exec.Command(binPath, args...)
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			assert.Error(t, cmd.Run(), args)
+			assert.Contains(t, stderr.String(), "input and output are the same file: -from "+args[len(args)-3]+", -to "+args[len(args)-1])
+			data, err := os.ReadFile(inputFile)
+			assert.NoError(t, err)
+			assert.Equal(t, "precious", string(data))
+		}
+	})
+
+	t.Run("ok, -notrunc keeps the bytes after the written ones", func(t *testing.T) {
+		outputFile := filepath.Join(t.TempDir(), "image")
+		for _, tc := range []struct {
+			args     []string
+			expected string
+		}{
+			{[]string{"-force"}, "ab"},
+			{[]string{"-force", "-notrunc"}, "ab23456789"},
+			{[]string{"-seek", "3"}, "012ab56789"},
+			{[]string{"-seek", "3", "-notrunc=false"}, "012ab"},
+			{[]string{"-seek-blocks", "2", "-block-size", "2"}, "0123ab6789"},
+			{[]string{"-seek-blocks", "2", "-block-size", "2", "-notrunc=false"}, "0123ab"},
+		} {
+			assert.NoError(t, os.WriteFile(outputFile, []byte("0123456789"), 0o600))
+			cmd = exec.Command(binPath, append([]string{"-quiet", "-to", outputFile}, tc.args...)...)
+			cmd.Stdin = strings.NewReader("ab")
+
+			assert.NoError(t, cmd.Run(), tc.args)
+			data, err := os.ReadFile(outputFile)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, string(data), tc.args)
+		}
+
+		cmd = exec.Command(binPath, "-dry-run", "-force", "-notrunc", "-to", outputFile)
+		cmd.Stdin = strings.NewReader("ab")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+
+		assert.NoError(t, cmd.Run())
+		assert.Contains(t, stdout.String(), "will be overwritten in place, keeping its length\n")
+	})
+
+	t.Run("fail, -notrunc together with -atomic", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-notrunc", "-atomic", "-to", filepath.Join(t.TempDir(), "out"))
+		cmd.Stdin = strings.NewReader("ab")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.Error(t, cmd.Run())
+		assert.Contains(t, stderr.String(), "-notrunc cannot be used with -atomic or -sparse")
+	})
+
+	t.Run("error, a full disk reports bytes written and free space", func(t *testing.T) {
+		if _, err := os.Stat("/dev/full"); err != nil || runtime.GOOS != "linux" {
+			t.Skip("no /dev/full")
+		}
+		cmd = exec.Command(binPath, "-force", "-to", "/dev/full")
+		cmd.Stdin = strings.NewReader("abc")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		var exitErr *exec.ExitError
+		assert.ErrorAs(t, err, &exitErr)
+		assert.Equal(t, 3, exitErr.ExitCode())
+		assert.Regexp(t, `can not write to /dev/full after 0 bytes written: no space left on device, the filesystem of /dev reports [0-9.]+ [KMGTP]?i?B free\n`, stderr.String())
+		assert.Contains(t, stderr.String(), "partial: 3 bytes read, 0 bytes written")
+	})
+
+	t.Run("ok, -read-block-size and -write-block-size split the blocks of both sides", func(t *testing.T) {
+		for _, tc := range []struct {
+			args    []string
+			records string
+		}{
+			{[]string{"-read-block-size", "4", "-write-block-size", "3"}, "2+1 records in\n3+1 records out\n"},
+			{[]string{"-read-block-size", "8", "-write-block-size", "2"}, "1+1 records in\n5+0 records out\n"},
+			{[]string{"-block-size", "2", "-write-block-size", "5"}, "5+0 records in\n2+0 records out\n"},
+			{[]string{"-block-size", "3"}, "3+1 records in\n3+1 records out\n"},
+		} {
+			cmd = exec.Command(binPath, append([]string{"-count", "100"}, tc.args...)...)
+			cmd.Stdin = strings.NewReader("0123456789")
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			assert.NoError(t, cmd.Run(), tc.args)
+			assert.Equal(t, "0123456789", stdout.String(), tc.args)
+			assert.Contains(t, stderr.String(), tc.records, tc.args)
+		}
+
+		outputFile := filepath.Join(t.TempDir(), "out")
+		assert.NoError(t, os.WriteFile(outputFile, []byte("......"), 0o600))
+		cmd = exec.Command(binPath, "-quiet", "-block-size", "100", "-read-block-size", "3", "-write-block-size", "2",
+			"-skip-blocks", "1", "-seek-blocks", "1", "-count", "1", "-to", outputFile)
+		cmd.Stdin = strings.NewReader("0123456789")
+
+		assert.NoError(t, cmd.Run())
+		data, err := os.ReadFile(outputFile)
+		assert.NoError(t, err)
+		assert.Equal(t, "..345.", string(data))
+	})
+
+	t.Run("fail, zero -read-block-size or -write-block-size", func(t *testing.T) {
+		for _, name := range []string{"-read-block-size", "-write-block-size"} {
+			cmd = exec.Command(binPath, name, "0")
+			cmd.Stdin = strings.NewReader("abc")
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			assert.Error(t, cmd.Run())
+			assert.Contains(t, stderr.String(), "-read-block-size and -write-block-size must be positive")
+		}
+	})
+
+	t.Run("ok, -limit-output caps the bytes written after -conv", func(t *testing.T) {
+		for _, tc := range []struct {
+			args     []string
+			input    string
+			expected string
+		}{
+			{[]string{"-conv", "upper_case", "-limit-output", "5"}, "straße", "STRA"},
+			{[]string{"-conv", "unix2dos", "-limit-output", "5"}, "a\nb\nc\n", "a\r\nb\r"},
+			{[]string{"-conv", "upper_case", "-limit-output", "5"}, "ааааа", "АА"},
+			{[]string{"-limit-output", "5"}, "ааааа", "аа\xd0"},
+			{[]string{"-conv", "base64_encode", "-limit-output", "4"}, "abcdef", "YWJj"},
+			{[]string{"-limit", "2", "-limit-output", "10"}, "abcdef", "ab"},
+			{[]string{"-limit", "10", "-limit-output", "3", "-block-size", "2"}, "abcdef", "abc"},
+			{[]string{"-limit-output", "3", "-write-block-size", "2"}, "abcdef", "abc"},
+			{[]string{"-limit-output", "0"}, "abcdef", ""},
+		} {
+			cmd = exec.Command(binPath, append([]string{"-quiet"}, tc.args...)...)
+			cmd.Stdin = strings.NewReader(tc.input)
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+
+			assert.NoError(t, cmd.Run(), tc.args)
+			assert.Equal(t, tc.expected, stdout.String(), tc.args)
+		}
+	})
+
+	t.Run("ok, -verify compares only the bytes kept by -limit-output", func(t *testing.T) {
+		dir := t.TempDir()
+		inputFile := filepath.Join(dir, "in")
+		outputFile := filepath.Join(dir, "out")
+		assert.NoError(t, os.WriteFile(inputFile, []byte(testInput[:1024]), 0o600))
+
+		cmd = exec.Command(binPath, "-quiet", "-from", inputFile, "-to", outputFile, "-verify", "-limit-output", "10")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.NoError(t, cmd.Run(), stderr.String())
+		data, err := os.ReadFile(outputFile)
+		assert.NoError(t, err)
+		assert.Equal(t, testInput[:10], string(data))
+	})
+
+	t.Run("ok, - is stdin in -from and stdout in -to, ./- is a file", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-verbose", "-from", "-", "-to", "-", "-offset", "3", "-limit", "4")
+		cmd.Stdin = strings.NewReader("0123456789")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.NoError(t, cmd.Run())
+		assert.Equal(t, "3456", stdout.String())
+		assert.Contains(t, stderr.String(), "reading from stdin\n")
+		assert.Contains(t, stderr.String(), "skipped 3 bytes of input via read\n")
+		assert.Contains(t, stderr.String(), "writing to stdout\n")
+
+		dir := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "-"), []byte("dash"), 0o600))
+		cmd = exec.Command(binPath, "-quiet", "-from", "./-", "-to", "./copy")
+		cmd.Dir = dir
+		stdout.Reset()
+		cmd.Stdout = stdout
+
+		assert.NoError(t, cmd.Run())
+		data, err := os.ReadFile(filepath.Join(dir, "copy"))
+		assert.NoError(t, err)
+		assert.Equal(t, "dash", string(data))
+
+		cmd = exec.Command(binPath, "-quiet", "-force", "-to", "./-")
+		cmd.Dir = dir
+		cmd.Stdin = strings.NewReader("new")
+		cmd.Stdout = stdout
+
+		assert.NoError(t, cmd.Run())
+		assert.Empty(t, stdout.String())
+		data, err = os.ReadFile(filepath.Join(dir, "-"))
+		assert.NoError(t, err)
+		assert.Equal(t, "new", string(data))
+	})
+
+	t.Run("fail, - together with other -from", func(t *testing.T) {
+		inputFile := filepath.Join(t.TempDir(), "in")
+		assert.NoError(t, os.WriteFile(inputFile, []byte("abc"), 0o600))
+		cmd = exec.Command(binPath, "-from", inputFile, "-from", "-")
+		cmd.Stdin = strings.NewReader("abc")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.Error(t, cmd.Run())
+		assert.Contains(t, stderr.String(), "- (stdin) must be the only -from, use ./- for a file named -")
+	})
+
+	t.Run("ok, zero: writes -limit NUL bytes with any block size and -sparse", func(t *testing.T) {
+		outputFile := filepath.Join(t.TempDir(), "disk.img")
+		cmd = exec.Command(binPath, "-from", "zero:", "-limit", "10000", "-block-size", "7", "-sparse", "-to", outputFile)
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.NoError(t, cmd.Run())
+		assert.Contains(t, stderr.String(), "10000 bytes read, 10000 bytes written, 1429 blocks")
+		assert.Contains(t, stderr.String(), ", 10000 bytes skipped as holes\n")
+		data, err := os.ReadFile(outputFile)
+		assert.NoError(t, err)
+		assert.Equal(t, make([]byte, 10000), data)
+
+		cmd = exec.Command(binPath, "-quiet", "-from", "zero:", "-count", "3", "-block-size", "5")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+
+		assert.NoError(t, cmd.Run())
+		assert.Equal(t, string(make([]byte, 15)), stdout.String())
+	})
+
+	t.Run("ok, zero: in -dry-run", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-dry-run", "-from", "zero:", "-limit", "1K", "-to", filepath.Join(t.TempDir(), "out"))
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+
+		assert.NoError(t, cmd.Run())
+		assert.Contains(t, stdout.String(), "source: zero:, endless NUL bytes\n")
+		assert.Contains(t, stdout.String(), "bytes to copy: 1024\n")
+	})
+
+	t.Run("fail, zero: without -limit or with other -from", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"-from", "zero:", "-from", "in.txt", "-limit", "3"},
+			{"-from", "zero:", "-limit", "3", "-offset", "-1"},
+		} {
+			cmd = exec.Command(binPath, args...)
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			assert.Error(t, cmd.Run())
+			assert.Contains(t, stderr.String(), "zero:")
+		}
+
+		cmd = exec.Command(binPath, "-from", "zero:")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+		assert.Error(t, cmd.Run())
+		assert.Contains(t, stderr.String(), "zero: never ends, bound it with -limit or -count")
+	})
+}
+
+type dribbleReader struct {
+	data []byte
+}
+
+func (dr *dribbleReader) Read(p []byte) (int, error) {
+	if len(dr.data) == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(time.Millisecond)
+	n := copy(p[:1], dr.data)
+	dr.data = dr.data[n:]
+	return n, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const aesKeySize = 32
+
+func newCTRStream(key, iv []byte) (cipher.Stream, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewCTR(block, iv), nil
+}
+
+type EncryptReader struct {
+	reader io.Reader
+	key    []byte
+	stream cipher.Stream
+	iv     []byte
+}
+
+func (er *EncryptReader) Read(p []byte) (n int, err error) {
+	if er.stream == nil {
+		iv := make([]byte, aes.BlockSize)
+		if _, err = rand.Read(iv); err != nil {
+			return 0, err
+		}
+		er.stream, err = newCTRStream(er.key, iv)
+		if err != nil {
+			return 0, err
+		}
+		er.iv = iv
+	}
+	if len(er.iv) != 0 {
+		er.iv, n = copyFromChecked(p, er.iv)
+		return n, nil
+	}
+
+	n, err = er.reader.Read(p)
+	er.stream.XORKeyStream(p[:n], p[:n])
+	return n, err
+}
+
+type DecryptReader struct {
+	reader io.Reader
+	key    []byte
+	stream cipher.Stream
+	iv     []byte
+}
+
+func (dr *DecryptReader) Read(p []byte) (n int, err error) {
+	if dr.stream != nil {
+		n, err = dr.reader.Read(p)
+		dr.stream.XORKeyStream(p[:n], p[:n])
+		return n, err
+	}
+
+	buffer := make([]byte, aes.BlockSize-len(dr.iv))
+	n, err = dr.reader.Read(buffer)
+	dr.iv = append(dr.iv, buffer[:n]...)
+	if len(dr.iv) == aes.BlockSize {
+		dr.stream, err = newCTRStream(dr.key, dr.iv)
+		if err != nil {
+			return 0, err
+		}
+		return dr.Read(p)
+	}
+	if errors.Is(err, io.EOF) {
+		return 0, fmt.Errorf("%w: input is too short to contain the %d-byte IV", ErrInvalidData, aes.BlockSize)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return dr.Read(p)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCryptoConversions(t *testing.T) {
+	binPath := composeBinaryPath()
+	cmd := exec.Command("go", "build", "-o", binPath, "./")
+	assert.NoError(t, cmd.Run())
+	defer func() {
+		assert.NoError(t, os.Remove(binPath))
+	}()
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	assert.NoError(t, os.WriteFile(keyFile, []byte(strings.Repeat("k", 32)), 0o600))
+
+	t.Run("ok, encrypt and decrypt round-trip with small blocks", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "encrypt", "-key-file", keyFile, "-block-size", "5")
+		cmd.Stdin = strings.NewReader(testInput)
+		encrypted := &strings.Builder{}
+		cmd.Stdout = encrypted
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.NoError(t, cmd.Run())
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Len(t, encrypted.String(), 16+len(testInput))
+		assert.NotContains(t, encrypted.String(), "hELlO")
+
+		cmd = exec.Command(binPath, "-quiet", "-conv", "decrypt", "-key-file", keyFile, "-block-size", "3")
+		cmd.Stdin = strings.NewReader(encrypted.String())
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, testInput, stdout.String())
+	})
+
+	t.Run("ok, encrypt uses a fresh IV every time", func(t *testing.T) {
+		outputs := make([]string, 2)
+		for i := range outputs {
+			cmd = exec.Command(binPath, "-quiet", "-conv", "encrypt", "-key-file", keyFile)
+			cmd.Stdin = strings.NewReader("same input")
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+
+			assert.NoError(t, cmd.Run())
+			outputs[i] = stdout.String()
+		}
+		assert.NotEqual(t, outputs[0], outputs[1])
+	})
+
+	t.Run("ok, decrypt applies offset and limit to ciphertext", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "encrypt", "-key-file", keyFile)
+		cmd.Stdin = strings.NewReader("secret")
+		encrypted := &strings.Builder{}
+		cmd.Stdout = encrypted
+		assert.NoError(t, cmd.Run())
+
+		cmd = exec.Command(binPath, "-quiet", "-conv", "decrypt", "-key-file", keyFile, "-offset", "4", "-limit", "19")
+		cmd.Stdin = strings.NewReader("JUNK" + encrypted.String() + "TAIL")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, "sec", stdout.String())
+	})
+
+	t.Run("error, decrypt input shorter than IV", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "decrypt", "-key-file", keyFile, "-block-size", "1")
+		cmd.Stdin = strings.NewReader("short")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "IV")
+	})
+
+	t.Run("error with wrong key length", func(t *testing.T) {
+		shortKey := filepath.Join(t.TempDir(), "short")
+		assert.NoError(t, os.WriteFile(shortKey, []byte("too short"), 0o600))
+
+		cmd = exec.Command(binPath, "-quiet", "-conv", "encrypt", "-key-file", shortKey)
+		cmd.Stdin = strings.NewReader(testInput)
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "32 bytes")
+		assert.Zero(t, stdout.Len())
+	})
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"unsafe"
+)
+
+const directAlignment = 4096
+
+func alignedBuffer(size int) []byte {
+	buffer := make([]byte, size+directAlignment)
+	shift := int(uintptr(unsafe.Pointer(&buffer[0])) & (directAlignment - 1))
+	if shift != 0 {
+		shift = directAlignment - shift
+	}
+	return buffer[shift : shift+size : shift+size]
+}
+
+func alignUp(size int) int {
+	return (size + directAlignment - 1) &^ (directAlignment - 1)
+}
+
+type DirectReader struct {
+	file    *os.File
+	buffer  []byte
+	pending []byte
+	pos     int64
+	started bool
+}
+
+func (dr *DirectReader) Read(p []byte) (n int, err error) {
+	if len(dr.pending) != 0 {
+		dr.pending, n = copyFromChecked(p, dr.pending)
+		return n, nil
+	}
+	if !dr.started {
+		dr.pos, err = dr.file.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return 0, err
+		}
+		dr.started = true
+	}
+
+	size := alignUp(len(p) + directAlignment)
+	if len(dr.buffer) < size {
+		dr.buffer = alignedBuffer(size)
+	}
+	start := dr.pos &^ (directAlignment - 1)
+	read, err := dr.file.ReadAt(dr.buffer[:size], start)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+	if skip := int(dr.pos - start); read > skip {
+		dr.pending = dr.buffer[skip:read]
+		dr.pos += int64(len(dr.pending))
+	}
+	if len(dr.pending) == 0 {
+		return 0, io.EOF
+	}
+	return dr.Read(p)
+}
+
+type directWriter struct {
+	file    *os.File
+	buffer  []byte
+	pending int
+	written int64
+}
+
+func newDirectWriter(file *os.File, blockSize uint64) *directWriter {
+	return &directWriter{file: file, buffer: alignedBuffer(alignUp(int(blockSize)))}
+}
+
+func (dw *directWriter) Write(p []byte) (int, error) {
+	total := len(p)
+	for len(p) != 0 {
+		n := copy(dw.buffer[dw.pending:], p)
+		dw.pending += n
+		p = p[n:]
+		if dw.pending == len(dw.buffer) {
+			if err := dw.flush(); err != nil {
+				return total - len(p), err
+			}
+		}
+	}
+	return total, nil
+}
+
+func (dw *directWriter) flush() error {
+	full := dw.pending &^ (directAlignment - 1)
+	if full != 0 {
+		n, err := dw.file.Write(dw.buffer[:full])
+		dw.written += int64(n)
+		if err != nil {
+			return err
+		}
+		dw.pending = copy(dw.buffer, dw.buffer[full:dw.pending])
+	}
+	return nil
+}
+
+func (dw *directWriter) flushTail() error {
+	if err := dw.flush(); err != nil {
+		return err
+	}
+	if dw.pending == 0 {
+		return nil
+	}
+
+	buffered, err := os.OpenFile(dw.file.Name(), os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	n, err := buffered.WriteAt(dw.buffer[:dw.pending], dw.written)
+	dw.written += int64(n)
+	dw.pending = 0
+	if err != nil {
+		_ = buffered.Close()
+		return err
+	}
+	diag.verbosef("wrote the unaligned tail of %d bytes without O_DIRECT", n)
+	return buffered.Close()
+}
+
+func (dw *directWriter) Sync() error {
+	if err := dw.flushTail(); err != nil {
+		return err
+	}
+	return dw.file.Sync()
+}
+
+func (dw *directWriter) Name() string {
+	return dw.file.Name()
+}
+
+func (dw *directWriter) Close() error {
+	if err := dw.flushTail(); err != nil {
+		_ = dw.file.Close()
+		return err
+	}
+	return dw.file.Close()
+}
@@ -0,0 +1,8 @@
+package main
+
+import "syscall"
+
+const (
+	oDirect         = syscall.O_DIRECT
+	directSupported = true
+)
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package main
+
+const (
+	oDirect         = 0
+	directSupported = false
+)
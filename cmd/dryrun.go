@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+func dryRun(opts *Options, output io.Writer) error {
+	size, err := describeSource(opts, output)
+	if err != nil {
+		return err
+	}
+	if err = checkSameFile(opts); err != nil {
+		return err
+	}
+	for _, path := range append([]string{opts.To}, opts.Tee...) {
+		destination := *opts
+		destination.To = path
+		if err = describeDestination(&destination, output); err != nil {
+			return err
+		}
+	}
+
+	conv := "none"
+	if len(opts.Conv) != 0 {
+		conv = strings.Join(opts.Conv, ",")
+	}
+	_, _ = fmt.Fprintf(output, "conv: %s\n", conv)
+
+	limit := int64(opts.Limit)
+	if isFlagSet("count") && opts.Count < math.MaxInt64/opts.ReadBlockSize {
+		limit = min(limit, int64(opts.Count*opts.ReadBlockSize))
+	}
+	if size < 0 {
+		if limit == math.MaxInt {
+			_, _ = fmt.Fprintln(output, "bytes to copy: unknown, until EOF")
+		} else {
+			_, _ = fmt.Fprintf(output, "bytes to copy: unknown, at most %d\n", limit)
+		}
+		return nil
+	}
+
+	offset := opts.Offset
+	if offset < 0 {
+		offset = max(size+offset, 0)
+	}
+	_, _ = fmt.Fprintf(output, "bytes to copy: %d\n", min(max(size-offset, 0), limit))
+	return nil
+}
+
+func describeSource(opts *Options, output io.Writer) (int64, error) {
+	if len(opts.From) == 0 {
+		_, _ = fmt.Fprintln(output, "source: stdin")
+		return -1, nil
+	}
+
+	var total int64
+	if isInfiniteSource(opts.From[0]) {
+		_, _ = fmt.Fprintf(output, "source: %s, endless NUL bytes\n", opts.From[0])
+		return math.MaxInt64, nil
+	}
+
+	for _, path := range opts.From {
+		size, err := describeSourceFile(path, opts, output)
+		if err != nil {
+			return 0, err
+		}
+		if size < 0 || total < 0 {
+			total = -1
+			continue
+		}
+		total += size
+	}
+	return total, nil
+}
+
+func describeSourceFile(path string, opts *Options, output io.Writer) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case stat.IsDir():
+		return 0, fmt.Errorf("source %s is a directory", path)
+	case !stat.Mode().IsRegular():
+		if opts.Offset < 0 {
+			return 0, fmt.Errorf("%w: negative -offset requires a regular file in -from", ErrInvalidFlag)
+		}
+		_, _ = fmt.Fprintf(output, "source: %s, not a regular file\n", path)
+		return -1, nil
+	case slices.Contains(opts.Conv, "bunzip2"):
+		_, _ = fmt.Fprintf(output, "source: %s, %d bytes compressed\n", path, stat.Size())
+		return -1, nil
+	}
+
+	_, _ = fmt.Fprintf(output, "source: %s, %d bytes\n", path, stat.Size())
+	return stat.Size(), nil
+}
+
+func describeDestination(opts *Options, output io.Writer) error {
+	if opts.To == "" {
+		_, _ = fmt.Fprintln(output, "destination: stdout")
+		return nil
+	}
+
+	missing, err := checkParent(opts)
+	if err != nil {
+		return err
+	}
+	if missing {
+		_, _ = fmt.Fprintf(output, "destination: %s, will be created with its parent directories\n", opts.To)
+		return nil
+	}
+
+	stat, err := os.Stat(opts.To)
+	switch {
+	case os.IsNotExist(err):
+		parent := filepath.Dir(opts.To)
+		if err = checkWritable(parent); err != nil {
+			return fmt.Errorf("parent directory of %s is not writable: %w", opts.To, err)
+		}
+		_, _ = fmt.Fprintf(output, "destination: %s, will be created\n", opts.To)
+		return nil
+	case err != nil:
+		return err
+	case stat.IsDir():
+		return fmt.Errorf("destination %s is a directory", opts.To)
+	}
+
+	action := "patched in place"
+	if !opts.NoTrunc {
+		action = "truncated at the seek offset and written"
+	}
+	switch {
+	case opts.Append:
+		action = "appended to"
+	case opts.Seek == 0 && opts.SeekBlocks == 0 && opts.Interactive && !opts.Force:
+		action = "overwritten if confirmed"
+	case opts.Seek == 0 && opts.SeekBlocks == 0:
+		if _, err = checkOverwrite(opts); err != nil {
+			return err
+		}
+		action = "overwritten"
+		if opts.NoTrunc {
+			action = "overwritten in place, keeping its length"
+		}
+	}
+	if err = checkWritable(opts.To); err != nil {
+		return fmt.Errorf("destination %s is not writable: %w", opts.To, err)
+	}
+	_, _ = fmt.Fprintf(output, "destination: %s, exists, %d bytes, will be %s\n", opts.To, stat.Size(), action)
+	return nil
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+type Base64EncodeReader struct {
+	reader  io.Reader
+	buffer  []byte
+	encoded []byte
+	eof     bool
+}
+
+func (br *Base64EncodeReader) Read(p []byte) (n int, err error) {
+	if len(br.encoded) != 0 {
+		br.encoded, n = copyFromChecked(p, br.encoded)
+		return n, nil
+	}
+	if br.eof {
+		return 0, io.EOF
+	}
+
+	buffer := make([]byte, len(p))
+	n, err = br.reader.Read(buffer)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+	br.buffer = append(br.buffer, buffer[:n]...)
+
+	if errors.Is(err, io.EOF) {
+		br.eof = true
+		br.encoded = base64.StdEncoding.AppendEncode(br.encoded, br.buffer)
+		br.buffer = nil
+		return br.Read(p)
+	}
+
+	full := len(br.buffer) - len(br.buffer)%3
+	br.encoded = base64.StdEncoding.AppendEncode(br.encoded, br.buffer[:full])
+	br.buffer = br.buffer[full:]
+	return br.Read(p)
+}
+
+var ErrInvalidData = fmt.Errorf("invalid input data")
+
+type Base64DecodeReader struct {
+	reader    io.Reader
+	quantum   [4]byte
+	positions [4]int64
+	filled    int
+	offset    int64
+	decoded   []byte
+	eof       bool
+}
+
+func isASCIISpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\v' || b == '\f'
+}
+
+func isBase64Char(b byte) bool {
+	return b >= 'A' && b <= 'Z' || b >= 'a' && b <= 'z' || b >= '0' && b <= '9' || b == '+' || b == '/' || b == '='
+}
+
+func (br *Base64DecodeReader) Read(p []byte) (n int, err error) {
+	if len(br.decoded) != 0 {
+		br.decoded, n = copyFromChecked(p, br.decoded)
+		return n, nil
+	}
+	if br.eof {
+		return 0, io.EOF
+	}
+
+	buffer := make([]byte, len(p))
+	n, readErr := br.reader.Read(buffer)
+	if readErr != nil && !errors.Is(readErr, io.EOF) {
+		return 0, readErr
+	}
+
+	for _, b := range buffer[:n] {
+		offset := br.offset
+		br.offset++
+		if isASCIISpace(b) {
+			continue
+		}
+		if !isBase64Char(b) {
+			return 0, fmt.Errorf("%w: illegal base64 character %q at offset %d", ErrInvalidData, b, offset)
+		}
+
+		br.quantum[br.filled] = b
+		br.positions[br.filled] = offset
+		br.filled++
+		if br.filled < len(br.quantum) {
+			continue
+		}
+
+		var corrupt base64.CorruptInputError
+		br.decoded, err = base64.StdEncoding.AppendDecode(br.decoded, br.quantum[:])
+		if errors.As(err, &corrupt) {
+			return 0, fmt.Errorf("%w: illegal base64 data at offset %d", ErrInvalidData, br.positions[corrupt])
+		}
+		br.filled = 0
+	}
+
+	if errors.Is(readErr, io.EOF) {
+		br.eof = true
+		if br.filled != 0 {
+			return 0, fmt.Errorf("%w: truncated base64 input at offset %d", ErrInvalidData, br.positions[0])
+		}
+	}
+	return br.Read(p)
+}
+
+type HexEncodeReader struct {
+	reader  io.Reader
+	encoded []byte
+	err     error
+}
+
+func (hr *HexEncodeReader) Read(p []byte) (n int, err error) {
+	if len(hr.encoded) != 0 {
+		hr.encoded, n = copyFromChecked(p, hr.encoded)
+		return n, nil
+	}
+	if hr.err != nil {
+		return 0, hr.err
+	}
+
+	buffer := make([]byte, len(p))
+	n, hr.err = hr.reader.Read(buffer)
+	hr.encoded = hex.AppendEncode(hr.encoded, buffer[:n])
+	if hr.err != nil && len(hr.encoded) == 0 {
+		return 0, hr.err
+	}
+	return hr.Read(p)
+}
+
+type HexDecodeReader struct {
+	reader  io.Reader
+	nibble  []byte
+	offset  int64
+	decoded []byte
+	eof     bool
+}
+
+func hexValue(b byte) (byte, bool) {
+	switch {
+	case b >= '0' && b <= '9':
+		return b - '0', true
+	case b >= 'a' && b <= 'f':
+		return b - 'a' + 10, true
+	case b >= 'A' && b <= 'F':
+		return b - 'A' + 10, true
+	}
+	return 0, false
+}
+
+func (hr *HexDecodeReader) Read(p []byte) (n int, err error) {
+	if len(hr.decoded) != 0 {
+		hr.decoded, n = copyFromChecked(p, hr.decoded)
+		return n, nil
+	}
+	if hr.eof {
+		return 0, io.EOF
+	}
+
+	buffer := make([]byte, len(p))
+	n, err = hr.reader.Read(buffer)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+
+	for _, b := range buffer[:n] {
+		offset := hr.offset
+		hr.offset++
+		if isASCIISpace(b) {
+			continue
+		}
+		value, ok := hexValue(b)
+		if !ok {
+			return 0, fmt.Errorf("%w: illegal hex character %q at offset %d", ErrInvalidData, b, offset)
+		}
+
+		if len(hr.nibble) == 0 {
+			hr.nibble = append(hr.nibble, value)
+			continue
+		}
+		hr.decoded = append(hr.decoded, hr.nibble[0]<<4|value)
+		hr.nibble = hr.nibble[:0]
+	}
+
+	if errors.Is(err, io.EOF) {
+		hr.eof = true
+		if len(hr.nibble) != 0 {
+			return 0, fmt.Errorf("%w: odd number of hex digits", ErrInvalidData)
+		}
+	}
+	return hr.Read(p)
+}
+
+const upperHexDigits = "0123456789ABCDEF"
+
+func isURLUnreserved(b byte) bool {
+	return b >= 'A' && b <= 'Z' || b >= 'a' && b <= 'z' || b >= '0' && b <= '9' ||
+		b == '-' || b == '.' || b == '_' || b == '~'
+}
+
+type URLEncodeReader struct {
+	reader  io.Reader
+	encoded []byte
+	err     error
+}
+
+func (ur *URLEncodeReader) Read(p []byte) (n int, err error) {
+	if len(ur.encoded) != 0 {
+		ur.encoded, n = copyFromChecked(p, ur.encoded)
+		return n, nil
+	}
+	if ur.err != nil {
+		return 0, ur.err
+	}
+
+	buffer := make([]byte, len(p))
+	n, ur.err = ur.reader.Read(buffer)
+	for _, b := range buffer[:n] {
+		if isURLUnreserved(b) {
+			ur.encoded = append(ur.encoded, b)
+			continue
+		}
+		ur.encoded = append(ur.encoded, '%', upperHexDigits[b>>4], upperHexDigits[b&0x0f])
+	}
+	if ur.err != nil && len(ur.encoded) == 0 {
+		return 0, ur.err
+	}
+	return ur.Read(p)
+}
+
+type URLDecodeReader struct {
+	reader       io.Reader
+	escape       []byte
+	escapeOffset int64
+	offset       int64
+	decoded      []byte
+}
+
+func (ur *URLDecodeReader) Read(p []byte) (n int, err error) {
+	if len(ur.decoded) != 0 {
+		ur.decoded, n = copyFromChecked(p, ur.decoded)
+		return n, nil
+	}
+
+	buffer := make([]byte, len(p))
+	n, err = ur.reader.Read(buffer)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+
+	for _, b := range buffer[:n] {
+		offset := ur.offset
+		ur.offset++
+		if len(ur.escape) == 0 {
+			if b == '%' {
+				ur.escape = append(ur.escape, b)
+				ur.escapeOffset = offset
+				continue
+			}
+			ur.decoded = append(ur.decoded, b)
+			continue
+		}
+
+		ur.escape = append(ur.escape, b)
+		if _, ok := hexValue(b); !ok {
+			return 0, fmt.Errorf("%w: malformed escape %q at offset %d", ErrInvalidData, ur.escape, ur.escapeOffset)
+		}
+		if len(ur.escape) == 3 {
+			high, _ := hexValue(ur.escape[1])
+			low, _ := hexValue(ur.escape[2])
+			ur.decoded = append(ur.decoded, high<<4|low)
+			ur.escape = ur.escape[:0]
+		}
+	}
+
+	if errors.Is(err, io.EOF) {
+		if len(ur.escape) != 0 {
+			return 0, fmt.Errorf("%w: truncated escape %q at offset %d", ErrInvalidData, ur.escape, ur.escapeOffset)
+		}
+		if len(ur.decoded) == 0 {
+			return 0, io.EOF
+		}
+	}
+	return ur.Read(p)
+}
+
+func appendJSONUnicode(dst []byte, r rune) []byte {
+	return fmt.Appendf(dst, "\\u%04x", r)
+}
+
+type JSONEscapeReader struct {
+	reader    io.Reader
+	asciiOnly bool
+	buffer    []byte
+	escaped   []byte
+}
+
+func (jr *JSONEscapeReader) Read(p []byte) (n int, err error) {
+	if len(jr.escaped) != 0 {
+		jr.escaped, n = copyFromChecked(p, jr.escaped)
+		return n, nil
+	}
+
+	buffer := make([]byte, len(p))
+	n, err = jr.reader.Read(buffer)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+	jr.buffer = append(jr.buffer, buffer[:n]...)
+
+	atEOF := errors.Is(err, io.EOF)
+	consumed := scanRunes(jr.buffer, atEOF, func(r rune, raw []byte) {
+		switch {
+		case r == '"' || r == '\\':
+			jr.escaped = append(jr.escaped, '\\', byte(r))
+		case r == '\n':
+			jr.escaped = append(jr.escaped, '\\', 'n')
+		case r == '\r':
+			jr.escaped = append(jr.escaped, '\\', 'r')
+		case r == '\t':
+			jr.escaped = append(jr.escaped, '\\', 't')
+		case r == '\b':
+			jr.escaped = append(jr.escaped, '\\', 'b')
+		case r == '\f':
+			jr.escaped = append(jr.escaped, '\\', 'f')
+		case r < 0x20 || r == 0x7f:
+			jr.escaped = appendJSONUnicode(jr.escaped, r)
+		case r == utf8.RuneError && len(raw) == 1:
+			jr.escaped = appendJSONUnicode(jr.escaped, utf8.RuneError)
+		case r < utf8.RuneSelf || !jr.asciiOnly:
+			jr.escaped = append(jr.escaped, raw...)
+		case r > 0xffff:
+			high, low := utf16.EncodeRune(r)
+			jr.escaped = appendJSONUnicode(jr.escaped, high)
+			jr.escaped = appendJSONUnicode(jr.escaped, low)
+		default:
+			jr.escaped = appendJSONUnicode(jr.escaped, r)
+		}
+	})
+	jr.buffer = jr.buffer[consumed:]
+
+	if atEOF && len(jr.escaped) == 0 {
+		return 0, io.EOF
+	}
+	return jr.Read(p)
+}
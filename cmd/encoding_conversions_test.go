@@ -0,0 +1,287 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodingConversions(t *testing.T) {
+	binPath := composeBinaryPath()
+	cmd := exec.Command("go", "build", "-o", binPath, "./")
+	assert.NoError(t, cmd.Run())
+	defer func() {
+		assert.NoError(t, os.Remove(binPath))
+	}()
+
+	t.Run("ok, base64_encode with block-size 1", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "base64_encode", "-block-size", "1")
+		cmd.Stdin = strings.NewReader(testInput)
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, base64.StdEncoding.EncodeToString([]byte(testInput)), stdout.String())
+	})
+
+	t.Run("ok, base64_encode pads only at the end", func(t *testing.T) {
+		for _, input := range []string{"", "a", "ab", "abc", "abcd"} {
+			cmd = exec.Command(binPath, "-quiet", "-conv", "base64_encode", "-block-size", "2")
+			cmd.Stdin = strings.NewReader(input)
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.NoError(t, err)
+			assert.Zero(t, stderr.Len(), stderr.String())
+			assert.Equal(t, base64.StdEncoding.EncodeToString([]byte(input)), stdout.String())
+		}
+	})
+
+	t.Run("ok, base64_decode with line-wrapped input", func(t *testing.T) {
+		encoded := base64.StdEncoding.EncodeToString([]byte(testInput))
+		wrapped := &strings.Builder{}
+		for len(encoded) > 76 {
+			wrapped.WriteString(encoded[:76] + "\n")
+			encoded = encoded[76:]
+		}
+		wrapped.WriteString(encoded + "\n")
+
+		cmd = exec.Command(binPath, "-quiet", "-conv", "base64_decode", "-block-size", "3")
+		cmd.Stdin = strings.NewReader(wrapped.String())
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, testInput, stdout.String())
+	})
+
+	t.Run("ok, base64_decode applies offset and limit to encoded input", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "base64_decode", "-offset", "4", "-limit", "4")
+		cmd.Stdin = strings.NewReader("YWJjZGVmZ2hp")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, "def", stdout.String())
+	})
+
+	t.Run("error, base64_decode with illegal character", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "base64_decode")
+		cmd.Stdin = strings.NewReader("YWJj\nZG*m")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "offset 7")
+	})
+
+	t.Run("ok, hex_encode with limit and block-size 1", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "hex_encode", "-limit", "5", "-block-size", "1")
+		cmd.Stdin = strings.NewReader("\x00\xffШabc")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, "00ffd0a861", stdout.String())
+	})
+
+	t.Run("ok, hex_encode with empty input", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "hex_encode")
+		cmd.Stdin = strings.NewReader("")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Zero(t, stdout.Len())
+	})
+
+	t.Run("ok, hex_decode with whitespace and block-size 1", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "hex_decode", "-block-size", "1")
+		cmd.Stdin = strings.NewReader("00 ff\nD0A8\t61\n")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, "\x00\xffШa", stdout.String())
+	})
+
+	t.Run("error, hex_decode with non-hex character", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "hex_decode")
+		cmd.Stdin = strings.NewReader("0a 1g")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "'g' at offset 4")
+	})
+
+	t.Run("error, hex_decode with odd number of digits", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "hex_decode")
+		cmd.Stdin = strings.NewReader("0a1")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.NotZero(t, stderr.Len())
+	})
+
+	t.Run("ok, url_encode with block-size 1", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "url_encode", "-block-size", "1")
+		cmd.Stdin = strings.NewReader("a b/c?d=é~")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, "a%20b%2Fc%3Fd%3D%C3%A9~", stdout.String())
+	})
+
+	t.Run("ok, url_encode and url_decode round-trip", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "url_encode,url_decode", "-block-size", "2")
+		cmd.Stdin = strings.NewReader(testInput)
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, testInput, stdout.String())
+	})
+
+	t.Run("error, url_decode with malformed escape", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "url_decode", "-block-size", "1")
+		cmd.Stdin = strings.NewReader("ok%2x")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "offset 2")
+	})
+
+	t.Run("ok, json_escape output is a valid JSON string", func(t *testing.T) {
+		for _, args := range [][]string{{}, {"-json-escape-ascii"}} {
+			input := testInput + "\"quoted\" \\ tab\t\x00\x7f\x01"
+			cmd = exec.Command(binPath, append([]string{"-quiet", "-conv", "json_escape", "-block-size", "1"}, args...)...)
+			cmd.Stdin = strings.NewReader(input)
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.NoError(t, err)
+			assert.Zero(t, stderr.Len(), stderr.String())
+			var decoded string
+			assert.NoError(t, json.Unmarshal([]byte(`"`+stdout.String()+`"`), &decoded))
+			assert.Equal(t, input, decoded)
+		}
+	})
+
+	t.Run("ok, json_escape with json-escape-ascii", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "json_escape", "-json-escape-ascii")
+		cmd.Stdin = strings.NewReader("я😊\n")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, `\u044f\ud83d\ude0a\n`, stdout.String())
+	})
+}
+
+type failingReader struct {
+	data []byte
+	err  error
+}
+
+func (fr *failingReader) Read(p []byte) (int, error) {
+	n := copy(p, fr.data)
+	fr.data = fr.data[n:]
+	err := fr.err
+	fr.err = io.EOF
+	return n, err
+}
+
+func TestEncodeReadersKeepReadErrors(t *testing.T) {
+	errRead := errors.New("read failed")
+	for name, reader := range map[string]io.Reader{
+		"hex_encode": &HexEncodeReader{reader: &failingReader{data: []byte("ab"), err: errRead}},
+		"url_encode": &URLEncodeReader{reader: &failingReader{data: []byte("a b"), err: errRead}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			data, err := io.ReadAll(reader)
+
+			assert.ErrorIs(t, err, errRead)
+			assert.NotEmpty(t, data)
+		})
+	}
+}
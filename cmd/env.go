@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const envPrefix = "CFU_"
+
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+func applyEnvironment(origins map[string]string) error {
+	explicit := setFlags()
+
+	var err error
+	flag.VisitAll(func(f *flag.Flag) {
+		name := envName(f.Name)
+		value, ok := os.LookupEnv(name)
+		if !ok || explicit[f.Name] || f.Name == "ignore-env" || f.Name == "config" || err != nil {
+			return
+		}
+		if setErr := flag.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("%w: invalid value %q of environment variable %s for -%s: %w", ErrInvalidFlag, value, name, f.Name, setErr)
+			return
+		}
+		origins[f.Name] = "environment variable " + name
+	})
+	return err
+}
+
+func withOrigin(err error, origins map[string]string) error {
+	names := make([]string, 0, len(origins))
+	for name := range origins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		mentioned := regexp.MustCompile(`(^|[^\w-])-` + regexp.QuoteMeta(name) + `($|[^\w-])`).MatchString(err.Error())
+		if mentioned || name == "conv" && errors.Is(err, ErrInvalidConv) {
+			return fmt.Errorf("%w (-%s is taken from %s)", err, name, origins[name])
+		}
+	}
+	return err
+}
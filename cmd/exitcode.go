@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"syscall"
+)
+
+const (
+	exitInvalid      = 1
+	exitSource       = 2
+	exitDestination  = 3
+	exitConversion   = 4
+	exitVerification = 5
+	exitTimeout      = 124
+	exitInterrupted  = 130
+)
+
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: code, err: err}
+}
+
+func sourceError(err error) error {
+	return withExitCode(exitSource, err)
+}
+
+func destinationError(err error) error {
+	return withExitCode(exitDestination, err)
+}
+
+// readError tells failures of the source itself from errors of the conv
+// readers stacked on top of it.
+func readError(err error) error {
+	var pathErr *fs.PathError
+	var errno syscall.Errno
+	var openErr *openSourceError
+	if errors.As(err, &pathErr) || errors.As(err, &errno) || errors.As(err, &openErr) {
+		return sourceError(err)
+	}
+	return withExitCode(exitConversion, err)
+}
+
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, ErrTimeout):
+		return exitTimeout
+	case errors.Is(err, ErrInterrupted):
+		return exitInterrupted
+	case errors.Is(err, ErrInvalidFlag), errors.Is(err, ErrInvalidConv):
+		return exitInvalid
+	case errors.Is(err, ErrVerification):
+		return exitVerification
+	case errors.Is(err, ErrDecompression):
+		return exitConversion
+	}
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	return exitInvalid
+}
+
+var errorClasses = map[int]string{
+	exitInvalid:      "invalid",
+	exitSource:       "source",
+	exitDestination:  "destination",
+	exitConversion:   "conversion",
+	exitVerification: "verification",
+	exitTimeout:      "timeout",
+	exitInterrupted:  "interrupted",
+}
+
+func errorClass(err error) string {
+	return errorClasses[exitCode(err)]
+}
@@ -0,0 +1,44 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package main
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFifoDestination(t *testing.T) {
+	binPath := composeBinaryPath()
+	cmd := exec.Command("go", "build", "-o", binPath, "./")
+	assert.NoError(t, cmd.Run())
+	defer func() {
+		assert.NoError(t, os.Remove(binPath))
+	}()
+
+	t.Run("fail, seek into a pipe", func(t *testing.T) {
+		fifo := filepath.Join(t.TempDir(), "fifo")
+		assert.NoError(t, syscall.Mkfifo(fifo, 0o600))
+		go func() {
+			reader, err := os.Open(fifo)
+			if err == nil {
+				_, _ = io.Copy(io.Discard, reader)
+				_ = reader.Close()
+			}
+		}()
+
+		cmd = exec.Command(binPath, "-seek", "3", "-to", fifo)
+		cmd.Stdin = strings.NewReader("abc")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.Error(t, cmd.Run())
+		assert.Contains(t, stderr.String(), "-seek requires a seekable -to")
+	})
+}
@@ -0,0 +1,13 @@
+//go:build !(linux || darwin || dragonfly || freebsd)
+
+package main
+
+import "errors"
+
+func isNoSpace(error) bool {
+	return false
+}
+
+func freeSpace(string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
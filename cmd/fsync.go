@@ -0,0 +1,49 @@
+package main
+
+import (
+	"io"
+	"os"
+)
+
+type fileSyncer interface {
+	Sync() error
+	Name() string
+}
+
+func syncWriter(writer io.Writer) error {
+	syncer, ok := writer.(fileSyncer)
+	if !ok {
+		diag.verbosef("-fsync ignored, the output is not a file")
+		return nil
+	}
+	if file, ok := writer.(*os.File); ok {
+		stat, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		if !stat.Mode().IsRegular() {
+			diag.verbosef("-fsync ignored, %s is not a regular file", file.Name())
+			return nil
+		}
+	}
+
+	if err := syncer.Sync(); err != nil {
+		return err
+	}
+	diag.verbosef("synced %s", syncer.Name())
+	return nil
+}
+
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	if err = dir.Sync(); err != nil {
+		return err
+	}
+	diag.verbosef("synced directory %s", path)
+	return nil
+}
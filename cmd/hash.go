@@ -0,0 +1,61 @@
+package main
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+)
+
+var hashAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+func parseHashes(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	names := strings.Split(value, ",")
+	for _, name := range names {
+		if _, ok := hashAlgorithms[name]; !ok {
+			return nil, fmt.Errorf("%w: unknown -hash %q, supported: md5, sha1, sha256, sha512", ErrInvalidFlag, name)
+		}
+	}
+	return names, nil
+}
+
+type digest struct {
+	name string
+	hash hash.Hash
+}
+
+type digests []digest
+
+func newDigests(names []string) digests {
+	result := make(digests, 0, len(names))
+	for _, name := range names {
+		result = append(result, digest{name: name, hash: hashAlgorithms[name]()})
+	}
+	return result
+}
+
+func (d digests) Write(p []byte) (int, error) {
+	for _, digest := range d {
+		digest.hash.Write(p)
+	}
+	return len(p), nil
+}
+
+func (d digests) print(output io.Writer, destination string) {
+	for _, digest := range d {
+		_, _ = fmt.Fprintf(output, "%s  %s\n", hex.EncodeToString(digest.hash.Sum(nil)), destination)
+	}
+}
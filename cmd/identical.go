@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+type skipIdenticalValue string
+
+func (s *skipIdenticalValue) String() string {
+	return string(*s)
+}
+
+func (s *skipIdenticalValue) Set(value string) error {
+	switch value {
+	case "size", "hash":
+		*s = skipIdenticalValue(value)
+		return nil
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("must be true, false, hash or size")
+	}
+	*s = ""
+	if enabled {
+		*s = "hash"
+	}
+	return nil
+}
+
+func (s *skipIdenticalValue) IsBoolFlag() bool {
+	return true
+}
+
+func fileDigest(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
+
+func identicalDestination(opts *Options) (bool, error) {
+	if len(opts.From) != 1 || opts.To == "" {
+		diag.verbosef("-skip-identical ignored, it needs a single -from file and a -to file")
+		return false, nil
+	}
+	destination, err := os.Stat(opts.To)
+	if os.IsNotExist(err) {
+		diag.verbosef("-skip-identical: %s does not exist, copying", opts.To)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	source, err := os.Stat(opts.From[0])
+	if err != nil {
+		return false, err
+	}
+	if !source.Mode().IsRegular() || !destination.Mode().IsRegular() {
+		diag.verbosef("-skip-identical: %s or %s is not a regular file, copying", opts.From[0], opts.To)
+		return false, nil
+	}
+	if source.Size() != destination.Size() {
+		diag.verbosef("-skip-identical: sizes of %s and %s differ, copying", opts.From[0], opts.To)
+		return false, nil
+	}
+	if opts.SkipIdentical == "size" {
+		diag.verbosef("-skip-identical: %s has the same size as %s, skipping", opts.To, opts.From[0])
+		return true, nil
+	}
+
+	sourceDigest, err := fileDigest(opts.From[0])
+	if err != nil {
+		return false, err
+	}
+	destinationDigest, err := fileDigest(opts.To)
+	if err != nil {
+		return false, err
+	}
+	if !bytes.Equal(sourceDigest, destinationDigest) {
+		diag.verbosef("-skip-identical: sha256 of %s and %s differ, copying", opts.From[0], opts.To)
+		return false, nil
+	}
+	diag.verbosef("-skip-identical: %s has the same sha256 as %s, skipping", opts.To, opts.From[0])
+	return true, nil
+}
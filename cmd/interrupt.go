@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+var ErrInterrupted = fmt.Errorf("interrupted")
+
+var interrupted = make(chan struct{})
+
+var signalNames = map[os.Signal]string{
+	os.Interrupt:    "SIGINT",
+	syscall.SIGTERM: "SIGTERM",
+}
+
+// handleInterrupts makes the first SIGINT or SIGTERM stop the copy at the
+// next block boundary and the second one exit immediately.
+func handleInterrupts() {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		diag.infof("%s received, stopping after the current block, send it again to exit immediately", signalNames[sig])
+		close(interrupted)
+		sig = <-signals
+		diag.errorf("%s received again, exiting", signalNames[sig])
+		os.Exit(exitInterrupted)
+	}()
+}
+
+func isInterrupted() bool {
+	select {
+	case <-interrupted:
+		return true
+	default:
+		return false
+	}
+}
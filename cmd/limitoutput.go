@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"unicode/utf8"
+)
+
+var errOutputLimit = fmt.Errorf("-limit-output reached")
+
+// runeConvs produce UTF-8 text, so -limit-output must not cut their output
+// inside a rune.
+var runeConvs = []string{
+	"lower_case", "upper_case", "swap_case", "title_case", "trim_spaces", "trim_left", "trim_right",
+	"squeeze_spaces", "nfc", "nfd", "expand_tabs", "unexpand", "strip_control", "fix_utf8",
+	"reverse_lines", "number_lines", "squeeze_blank", "head_lines", "tail_lines", "wrap", "map",
+	"replace", "match", "exclude", "json_escape",
+}
+
+func runeOutput(opts *Options) bool {
+	if opts.OutputEncoding != nil {
+		return false
+	}
+	for i := len(opts.Conv) - 1; i >= 0; i-- {
+		switch opts.Conv[i] {
+		case "sync", "noerror", "bunzip2":
+			continue
+		}
+		return slices.Contains(runeConvs, opts.Conv[i])
+	}
+	return false
+}
+
+// cutOutput shortens block to at most limit bytes, backing off to the start
+// of a rune split by the cut when runes is set.
+func cutOutput(block []byte, limit int64, runes bool) []byte {
+	cut := block[:max(limit, 0)]
+	if !runes {
+		return cut
+	}
+	for i := len(cut) - 1; i >= 0 && i >= len(cut)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(cut[i]) {
+			if !utf8.FullRune(cut[i:]) {
+				return cut[:i]
+			}
+			break
+		}
+	}
+	return cut
+}
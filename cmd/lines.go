@@ -0,0 +1,617 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+type NewlineReader struct {
+	reader    io.Reader
+	loneCR    byte
+	pendingCR bool
+	converted []byte
+}
+
+func (nr *NewlineReader) Read(p []byte) (n int, err error) {
+	if len(nr.converted) != 0 {
+		nr.converted, n = copyFromChecked(p, nr.converted)
+		return n, nil
+	}
+
+	buffer := make([]byte, len(p))
+	n, err = nr.reader.Read(buffer)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+
+	for _, b := range buffer[:n] {
+		if nr.pendingCR {
+			nr.pendingCR = false
+			if b == '\n' {
+				nr.converted = append(nr.converted, '\n')
+				continue
+			}
+			nr.converted = append(nr.converted, nr.loneCR)
+		}
+		if b == '\r' {
+			nr.pendingCR = true
+			continue
+		}
+		nr.converted = append(nr.converted, b)
+	}
+
+	if errors.Is(err, io.EOF) {
+		if nr.pendingCR {
+			nr.pendingCR = false
+			nr.converted = append(nr.converted, nr.loneCR)
+		}
+		if len(nr.converted) == 0 {
+			return 0, io.EOF
+		}
+	}
+	return nr.Read(p)
+}
+
+type Unix2DosReader struct {
+	reader    io.Reader
+	prevCR    bool
+	converted []byte
+}
+
+func (ur *Unix2DosReader) Read(p []byte) (n int, err error) {
+	if len(ur.converted) != 0 {
+		ur.converted, n = copyFromChecked(p, ur.converted)
+		return n, nil
+	}
+
+	buffer := make([]byte, len(p))
+	n, err = ur.reader.Read(buffer)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+
+	for _, b := range buffer[:n] {
+		if b == '\n' && !ur.prevCR {
+			ur.converted = append(ur.converted, '\r')
+		}
+		ur.converted = append(ur.converted, b)
+		ur.prevCR = b == '\r'
+	}
+
+	if errors.Is(err, io.EOF) && len(ur.converted) == 0 {
+		return 0, io.EOF
+	}
+	return ur.Read(p)
+}
+
+type ExpandTabsReader struct {
+	reader   io.Reader
+	tabWidth int
+	column   int
+	buffer   []byte
+	expanded []byte
+}
+
+func (er *ExpandTabsReader) Read(p []byte) (n int, err error) {
+	if len(er.expanded) != 0 {
+		er.expanded, n = copyFromChecked(p, er.expanded)
+		return n, nil
+	}
+
+	buffer := make([]byte, len(p))
+	n, err = er.reader.Read(buffer)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+	er.buffer = append(er.buffer, buffer[:n]...)
+
+	atEOF := errors.Is(err, io.EOF)
+	consumed := scanRunes(er.buffer, atEOF, func(r rune, raw []byte) {
+		switch r {
+		case '\t':
+			spaces := er.tabWidth - er.column%er.tabWidth
+			er.expanded = append(er.expanded, bytes.Repeat([]byte{' '}, spaces)...)
+			er.column += spaces
+		case '\n', '\r':
+			er.expanded = append(er.expanded, raw...)
+			er.column = 0
+		default:
+			er.expanded = append(er.expanded, raw...)
+			er.column++
+		}
+	})
+	er.buffer = er.buffer[consumed:]
+
+	if atEOF && len(er.expanded) == 0 {
+		return 0, io.EOF
+	}
+	return er.Read(p)
+}
+
+type UnexpandReader struct {
+	reader     io.Reader
+	tabWidth   int
+	midLine    bool
+	column     int
+	unexpanded []byte
+}
+
+func (ur *UnexpandReader) flushIndent() {
+	ur.unexpanded = append(ur.unexpanded, bytes.Repeat([]byte{'\t'}, ur.column/ur.tabWidth)...)
+	ur.unexpanded = append(ur.unexpanded, bytes.Repeat([]byte{' '}, ur.column%ur.tabWidth)...)
+	ur.column = 0
+}
+
+func (ur *UnexpandReader) Read(p []byte) (n int, err error) {
+	if len(ur.unexpanded) != 0 {
+		ur.unexpanded, n = copyFromChecked(p, ur.unexpanded)
+		return n, nil
+	}
+
+	buffer := make([]byte, len(p))
+	n, err = ur.reader.Read(buffer)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+
+	for _, b := range buffer[:n] {
+		if ur.midLine {
+			ur.unexpanded = append(ur.unexpanded, b)
+			ur.midLine = b != '\n'
+			continue
+		}
+
+		switch b {
+		case ' ':
+			ur.column++
+		case '\t':
+			ur.column += ur.tabWidth - ur.column%ur.tabWidth
+		default:
+			ur.flushIndent()
+			ur.unexpanded = append(ur.unexpanded, b)
+			ur.midLine = b != '\n'
+		}
+	}
+
+	if errors.Is(err, io.EOF) {
+		ur.flushIndent()
+		if len(ur.unexpanded) == 0 {
+			return 0, io.EOF
+		}
+	}
+	return ur.Read(p)
+}
+
+type ReverseLinesReader struct {
+	reader   io.Reader
+	maxSpool uint64
+	source   io.ReaderAt
+	pos      int64
+	started  bool
+	spool    *os.File
+	pending  []byte
+	reversed []byte
+}
+
+func seekableSection(file *os.File, offset int64, limit uint64) *io.SectionReader {
+	stat, err := file.Stat()
+	if err != nil || !stat.Mode().IsRegular() {
+		return nil
+	}
+
+	start := min(offset, stat.Size())
+	return io.NewSectionReader(file, start, min(int64(limit), stat.Size()-start))
+}
+
+func (rr *ReverseLinesReader) spoolInput() error {
+	spool, err := os.CreateTemp("", "copying-files-utility-spool-*")
+	if err != nil {
+		return err
+	}
+	rr.spool = spool
+
+	maxSpool := int64(min(rr.maxSpool, math.MaxInt64-1))
+	n, err := io.Copy(spool, io.LimitReader(rr.reader, maxSpool+1))
+	if err != nil {
+		return err
+	}
+	if n > maxSpool {
+		return fmt.Errorf("input of reverse_lines exceeds -max-spool of %d bytes", rr.maxSpool)
+	}
+
+	rr.source = spool
+	rr.pos = n
+	return nil
+}
+
+func (rr *ReverseLinesReader) removeSpool() {
+	if rr.spool == nil {
+		return
+	}
+	_ = rr.spool.Close()
+	_ = os.Remove(rr.spool.Name())
+	rr.spool = nil
+}
+
+// Close removes the spool when the copy stops before reverse_lines
+// reaches the start of its input.
+func (rr *ReverseLinesReader) Close() error {
+	rr.removeSpool()
+	return nil
+}
+
+func (rr *ReverseLinesReader) emitLine(line []byte) {
+	rr.reversed = append(rr.reversed, line...)
+	if line[len(line)-1] != '\n' {
+		rr.reversed = append(rr.reversed, '\n')
+	}
+}
+
+func (rr *ReverseLinesReader) Read(p []byte) (n int, err error) {
+	if len(rr.reversed) != 0 {
+		rr.reversed, n = copyFromChecked(p, rr.reversed)
+		return n, nil
+	}
+
+	if !rr.started && rr.source == nil {
+		rr.started = true
+		if err = rr.spoolInput(); err != nil {
+			rr.removeSpool()
+			return 0, err
+		}
+	}
+
+	for len(rr.reversed) == 0 {
+		if i := bytes.LastIndexByte(rr.pending[:max(len(rr.pending)-1, 0)], '\n'); i >= 0 {
+			rr.emitLine(rr.pending[i+1:])
+			rr.pending = rr.pending[:i+1]
+			break
+		}
+		if rr.pos == 0 {
+			if len(rr.pending) == 0 {
+				rr.removeSpool()
+				return 0, io.EOF
+			}
+			rr.emitLine(rr.pending)
+			rr.pending = nil
+			break
+		}
+
+		chunk := make([]byte, min(int64(len(p)), rr.pos))
+		n, err = rr.source.ReadAt(chunk, rr.pos-int64(len(chunk)))
+		if n < len(chunk) {
+			rr.removeSpool()
+			return 0, err
+		}
+		rr.pending = append(chunk, rr.pending...)
+		rr.pos -= int64(len(chunk))
+	}
+	return rr.Read(p)
+}
+
+type NumberLinesReader struct {
+	reader   io.Reader
+	line     int
+	midLine  bool
+	numbered []byte
+	err      error
+}
+
+func (nr *NumberLinesReader) Read(p []byte) (n int, err error) {
+	if len(nr.numbered) != 0 {
+		nr.numbered, n = copyFromChecked(p, nr.numbered)
+		return n, nil
+	}
+	if nr.err != nil {
+		return 0, nr.err
+	}
+
+	buffer := make([]byte, len(p))
+	n, nr.err = nr.reader.Read(buffer)
+	for _, b := range buffer[:n] {
+		if !nr.midLine {
+			nr.line++
+			nr.numbered = fmt.Appendf(nr.numbered, "%6d\t", nr.line)
+		}
+		nr.numbered = append(nr.numbered, b)
+		nr.midLine = b != '\n'
+	}
+
+	if nr.err != nil && len(nr.numbered) == 0 {
+		return 0, nr.err
+	}
+	return nr.Read(p)
+}
+
+type SqueezeBlankReader struct {
+	reader     io.Reader
+	whitespace bool
+	midLine    bool
+	prevBlank  bool
+	line       []byte
+	squeezed   []byte
+	err        error
+}
+
+func (sr *SqueezeBlankReader) isBlankByte(b byte) bool {
+	if sr.whitespace {
+		return isASCIISpace(b)
+	}
+	return b == '\r' && len(sr.line) == 0
+}
+
+func (sr *SqueezeBlankReader) Read(p []byte) (n int, err error) {
+	if len(sr.squeezed) != 0 {
+		sr.squeezed, n = copyFromChecked(p, sr.squeezed)
+		return n, nil
+	}
+	if sr.err != nil {
+		return 0, sr.err
+	}
+
+	buffer := make([]byte, len(p))
+	n, sr.err = sr.reader.Read(buffer)
+	for _, b := range buffer[:n] {
+		switch {
+		case sr.midLine:
+			sr.squeezed = append(sr.squeezed, b)
+			if b == '\n' {
+				sr.midLine = false
+				sr.prevBlank = false
+			}
+		case b == '\n':
+			if !sr.prevBlank {
+				sr.squeezed = append(sr.squeezed, sr.line...)
+				sr.squeezed = append(sr.squeezed, b)
+			}
+			sr.prevBlank = true
+			sr.line = sr.line[:0]
+		case sr.isBlankByte(b):
+			sr.line = append(sr.line, b)
+		default:
+			sr.squeezed = append(sr.squeezed, sr.line...)
+			sr.squeezed = append(sr.squeezed, b)
+			sr.line = sr.line[:0]
+			sr.midLine = true
+		}
+	}
+
+	if sr.err != nil {
+		sr.squeezed = append(sr.squeezed, sr.line...)
+		sr.line = sr.line[:0]
+		if len(sr.squeezed) == 0 {
+			return 0, sr.err
+		}
+	}
+	return sr.Read(p)
+}
+
+type WrapReader struct {
+	reader  io.Reader
+	width   int
+	column  int
+	buffer  []byte
+	wrapped []byte
+}
+
+func (wr *WrapReader) Read(p []byte) (n int, err error) {
+	if len(wr.wrapped) != 0 {
+		wr.wrapped, n = copyFromChecked(p, wr.wrapped)
+		return n, nil
+	}
+
+	buffer := make([]byte, len(p))
+	n, err = wr.reader.Read(buffer)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+	wr.buffer = append(wr.buffer, buffer[:n]...)
+
+	atEOF := errors.Is(err, io.EOF)
+	consumed := scanRunes(wr.buffer, atEOF, func(r rune, raw []byte) {
+		if r == '\n' {
+			wr.wrapped = append(wr.wrapped, raw...)
+			wr.column = 0
+			return
+		}
+		if wr.column == wr.width {
+			wr.wrapped = append(wr.wrapped, '\n')
+			wr.column = 0
+		}
+		wr.wrapped = append(wr.wrapped, raw...)
+		wr.column++
+	})
+	wr.buffer = wr.buffer[consumed:]
+
+	if atEOF && len(wr.wrapped) == 0 {
+		return 0, io.EOF
+	}
+	return wr.Read(p)
+}
+
+type HeadLinesReader struct {
+	reader    io.Reader
+	remaining uint64
+}
+
+func (hr *HeadLinesReader) Read(p []byte) (n int, err error) {
+	if hr.remaining == 0 {
+		return 0, io.EOF
+	}
+
+	n, err = hr.reader.Read(p)
+	for i, b := range p[:n] {
+		if b != '\n' {
+			continue
+		}
+		hr.remaining--
+		if hr.remaining == 0 {
+			return i + 1, nil
+		}
+	}
+	return n, err
+}
+
+type TailLinesReader struct {
+	reader io.Reader
+	lines  [][]byte
+	next   int
+	full   bool
+	line   []byte
+	tail   []byte
+	done   bool
+}
+
+func (tr *TailLinesReader) push() {
+	if len(tr.lines) == 0 {
+		tr.line = tr.line[:0]
+		return
+	}
+	tr.lines[tr.next] = append(tr.lines[tr.next][:0], tr.line...)
+	tr.line = tr.line[:0]
+	tr.next = (tr.next + 1) % len(tr.lines)
+	tr.full = tr.full || tr.next == 0
+}
+
+func (tr *TailLinesReader) Read(p []byte) (n int, err error) {
+	if len(tr.tail) != 0 {
+		tr.tail, n = copyFromChecked(p, tr.tail)
+		return n, nil
+	}
+	if tr.done {
+		return 0, io.EOF
+	}
+
+	buffer := make([]byte, len(p))
+	n, err = tr.reader.Read(buffer)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+
+	for _, b := range buffer[:n] {
+		tr.line = append(tr.line, b)
+		if b == '\n' {
+			tr.push()
+		}
+	}
+
+	if errors.Is(err, io.EOF) {
+		if len(tr.line) != 0 {
+			tr.push()
+		}
+		if tr.full {
+			for _, line := range tr.lines[tr.next:] {
+				tr.tail = append(tr.tail, line...)
+			}
+		}
+		for _, line := range tr.lines[:tr.next] {
+			tr.tail = append(tr.tail, line...)
+		}
+		tr.done = true
+	}
+	return tr.Read(p)
+}
+
+type BlockReader struct {
+	reader     io.Reader
+	cbs        uint64
+	report     io.Writer
+	column     uint64
+	truncating bool
+	truncated  uint64
+	done       bool
+	blocked    []byte
+}
+
+func (br *BlockReader) padRecord() {
+	br.blocked = append(br.blocked, bytes.Repeat([]byte{' '}, int(br.cbs-br.column))...)
+	br.column = 0
+	br.truncating = false
+}
+
+func (br *BlockReader) Read(p []byte) (n int, err error) {
+	if len(br.blocked) != 0 {
+		br.blocked, n = copyFromChecked(p, br.blocked)
+		return n, nil
+	}
+	if br.done {
+		return 0, io.EOF
+	}
+
+	buffer := make([]byte, len(p))
+	n, err = br.reader.Read(buffer)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+
+	for _, b := range buffer[:n] {
+		switch {
+		case b == '\n':
+			br.padRecord()
+		case br.column < br.cbs:
+			br.blocked = append(br.blocked, b)
+			br.column++
+		case !br.truncating:
+			br.truncating = true
+			br.truncated++
+		}
+	}
+
+	if errors.Is(err, io.EOF) {
+		if br.column != 0 || br.truncating {
+			br.padRecord()
+		}
+		if br.truncated != 0 {
+			_, _ = fmt.Fprintf(br.report, "%d lines truncated to -cbs %d\n", br.truncated, br.cbs)
+		}
+		br.done = true
+	}
+	return br.Read(p)
+}
+
+type UnblockReader struct {
+	reader    io.Reader
+	cbs       uint64
+	record    []byte
+	unblocked []byte
+}
+
+func (ur *UnblockReader) flushRecord() {
+	ur.unblocked = append(ur.unblocked, bytes.TrimRight(ur.record, " ")...)
+	ur.unblocked = append(ur.unblocked, '\n')
+	ur.record = ur.record[:0]
+}
+
+func (ur *UnblockReader) Read(p []byte) (n int, err error) {
+	if len(ur.unblocked) != 0 {
+		ur.unblocked, n = copyFromChecked(p, ur.unblocked)
+		return n, nil
+	}
+
+	buffer := make([]byte, len(p))
+	n, err = ur.reader.Read(buffer)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+
+	for _, b := range buffer[:n] {
+		ur.record = append(ur.record, b)
+		if uint64(len(ur.record)) == ur.cbs {
+			ur.flushRecord()
+		}
+	}
+
+	if errors.Is(err, io.EOF) {
+		if len(ur.record) != 0 {
+			ur.flushRecord()
+		}
+		if len(ur.unblocked) == 0 {
+			return 0, io.EOF
+		}
+	}
+	return ur.Read(p)
+}
@@ -0,0 +1,474 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinesConversions(t *testing.T) {
+	binPath := composeBinaryPath()
+	cmd := exec.Command("go", "build", "-o", binPath, "./")
+	assert.NoError(t, cmd.Run())
+	defer func() {
+		assert.NoError(t, os.Remove(binPath))
+	}()
+
+	t.Run("ok, dos2unix with CRLF split between blocks", func(t *testing.T) {
+		for _, blockSize := range []string{"1", "2", "1024"} {
+			cmd = exec.Command(binPath, "-quiet", "-conv", "dos2unix", "-block-size", blockSize)
+			cmd.Stdin = strings.NewReader("a\r\nb\rc\n\r\r\nd\r")
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.NoError(t, err)
+			assert.Zero(t, stderr.Len(), stderr.String())
+			assert.Equal(t, "a\nb\rc\n\r\nd\r", stdout.String())
+		}
+	})
+
+	t.Run("ok, dos2unix with trim_spaces and upper_case", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "upper_case,dos2unix,trim_spaces", "-block-size", "3")
+		cmd.Stdin = strings.NewReader(" \r\nline one\r\nстрока два\r\n")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, "LINE ONE\nСТРОКА ДВА", stdout.String())
+	})
+
+	t.Run("ok, dos2unix then unix2dos runs in order", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "dos2unix,unix2dos")
+		cmd.Stdin = strings.NewReader("a\r\nb\n")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+
+		assert.NoError(t, cmd.Run())
+		assert.Equal(t, "a\r\nb\r\n", stdout.String())
+	})
+
+	t.Run("ok, unix2dos keeps existing CRLF", func(t *testing.T) {
+		for _, blockSize := range []string{"1", "2", "1024"} {
+			cmd = exec.Command(binPath, "-quiet", "-conv", "unix2dos", "-block-size", blockSize)
+			cmd.Stdin = strings.NewReader("a\nb\r\nc\rd\n\n")
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.NoError(t, err)
+			assert.Zero(t, stderr.Len(), stderr.String())
+			assert.Equal(t, "a\r\nb\r\nc\rd\r\n\r\n", stdout.String())
+		}
+	})
+
+	t.Run("ok, unix2dos limit counts input bytes", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "unix2dos", "-limit", "4")
+		cmd.Stdin = strings.NewReader("a\nb\nc\n")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, "a\r\nb\r\n", stdout.String())
+	})
+
+	t.Run("ok, expand_tabs with multi-byte runes and CRLF", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "expand_tabs", "-tab-width", "4", "-block-size", "1")
+		cmd.Stdin = strings.NewReader("\tx\r\nяб\tz\n12345\t!")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, "    x\r\nяб  z\n12345   !", stdout.String())
+	})
+
+	t.Run("error with invalid tab-width", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "expand_tabs", "-tab-width", "0")
+		cmd.Stdin = strings.NewReader(testInput)
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.NotZero(t, stderr.Len())
+		assert.Zero(t, stdout.Len())
+	})
+
+	t.Run("ok, unexpand with runs not multiple of tab-width", func(t *testing.T) {
+		for _, blockSize := range []string{"1", "3", "1024"} {
+			cmd = exec.Command(binPath, "-quiet", "-conv", "unexpand", "-tab-width", "4", "-block-size", blockSize)
+			cmd.Stdin = strings.NewReader("      a    b\n    c\n  \td\n   e\n        ")
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.NoError(t, err)
+			assert.Zero(t, stderr.Len(), stderr.String())
+			assert.Equal(t, "\t  a    b\n\tc\n\td\n   e\n\t\t", stdout.String())
+		}
+	})
+
+	t.Run("ok, reverse_lines with seekable file input", func(t *testing.T) {
+		testFile, err := os.CreateTemp("", "reverse-*.txt")
+		assert.NoError(t, err)
+		defer os.Remove(testFile.Name())
+		_, err = testFile.WriteString("skip\nfirst\n" + strings.Repeat("long", 10) + "\nlast")
+		assert.NoError(t, err)
+		assert.NoError(t, testFile.Close())
+
+		cmd = exec.Command(binPath, "-quiet", "-from", testFile.Name(), "-offset", "5", "-conv", "reverse_lines", "-block-size", "3")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err = cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, "last\n"+strings.Repeat("long", 10)+"\nfirst\n", stdout.String())
+	})
+
+	t.Run("ok, reverse_lines of a file counts bytes read and keeps -max-rate and -retries", func(t *testing.T) {
+		inputFile := filepath.Join(t.TempDir(), "in.txt")
+		assert.NoError(t, os.WriteFile(inputFile, []byte("a\nb\nc\n"), 0o600))
+
+		cmd = exec.Command(binPath, "-verbose", "-from", inputFile, "-conv", "reverse_lines")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.NoError(t, cmd.Run())
+		assert.Equal(t, "c\nb\na\n", stdout.String())
+		assert.Contains(t, stderr.String(), "reverse_lines reads the source backwards without spooling\n")
+		assert.Contains(t, stderr.String(), "6 bytes read, 6 bytes written")
+
+		for _, flag := range [][]string{{"-max-rate", "1M"}, {"-retries", "1"}} {
+			cmd = exec.Command(binPath, append([]string{"-verbose", "-from", inputFile, "-conv", "reverse_lines"}, flag...)...)
+			stdout.Reset()
+			cmd.Stdout = stdout
+			stderr.Reset()
+			cmd.Stderr = stderr
+
+			assert.NoError(t, cmd.Run())
+			assert.Equal(t, "c\nb\na\n", stdout.String())
+			assert.NotContains(t, stderr.String(), "without spooling", flag)
+			assert.Contains(t, stderr.String(), "6 bytes read, 6 bytes written", flag)
+		}
+	})
+
+	t.Run("ok, reverse_lines with stdin input", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "trim_spaces,reverse_lines", "-block-size", "5")
+		cmd.Stdin = strings.NewReader(testInput)
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		lines := strings.Split(strings.TrimSpace(testInput), "\n")
+		slices.Reverse(lines)
+		assert.Equal(t, strings.Join(lines, "\n")+"\n", stdout.String())
+	})
+
+	t.Run("ok, reverse_lines removes its spool when the copy stops early", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		for _, args := range [][]string{{"-count", "1"}, {"-limit-output", "5"}} {
+			cmd = exec.Command(binPath, append([]string{"-quiet", "-conv", "reverse_lines", "-block-size", "4"}, args...)...)
+			cmd.Env = append(os.Environ(), "TMPDIR="+tmpDir)
+			cmd.Stdin = strings.NewReader("a\nb\nc\nd\ne\nf\n")
+
+			assert.NoError(t, cmd.Run(), args)
+			entries, err := os.ReadDir(tmpDir)
+			assert.NoError(t, err)
+			assert.Empty(t, entries, args)
+		}
+	})
+
+	t.Run("error, reverse_lines input exceeds max-spool", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "reverse_lines", "-max-spool", "10")
+		cmd.Stdin = strings.NewReader(testInput)
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "max-spool")
+		assert.Zero(t, stdout.Len())
+	})
+
+	t.Run("ok, number_lines with unterminated last line", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "number_lines", "-block-size", "1")
+		cmd.Stdin = strings.NewReader("a\n\nb")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, "     1\ta\n     2\t\n     3\tb", stdout.String())
+	})
+
+	t.Run("ok, number_lines grows past six digits", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "number_lines", "-block-size", "4096")
+		cmd.Stdin = strings.NewReader(strings.Repeat("\n", 1000001))
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.True(t, strings.HasSuffix(stdout.String(), "999999\t\n1000000\t\n1000001\t\n"))
+	})
+
+	t.Run("ok, squeeze_blank with CRLF", func(t *testing.T) {
+		for _, blockSize := range []string{"1", "1024"} {
+			cmd = exec.Command(binPath, "-quiet", "-conv", "squeeze_blank", "-block-size", blockSize)
+			cmd.Stdin = strings.NewReader("a\n\n\n\nb\r\n\r\n\r\n \n \nc\n\n")
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.NoError(t, err)
+			assert.Zero(t, stderr.Len(), stderr.String())
+			assert.Equal(t, "a\n\nb\r\n\r\n \n \nc\n\n", stdout.String())
+		}
+	})
+
+	t.Run("ok, squeeze_blank with blank-whitespace", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "squeeze_blank", "-blank-whitespace", "-block-size", "2")
+		cmd.Stdin = strings.NewReader("a\n \n\t\n\nb\n  c\n")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, "a\n \nb\n  c\n", stdout.String())
+	})
+
+	t.Run("ok, wrap with multi-byte runes split between blocks", func(t *testing.T) {
+		for _, blockSize := range []string{"1", "2", "1024"} {
+			cmd = exec.Command(binPath, "-quiet", "-conv", "wrap", "-wrap-width", "3", "-block-size", blockSize)
+			cmd.Stdin = strings.NewReader("абвгдеж\nabc\n\nxy\n{\"k\":1}")
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.NoError(t, err)
+			assert.Zero(t, stderr.Len(), stderr.String())
+			assert.Equal(t, "абв\nгде\nж\nabc\n\nxy\n{\"k\n\":1\n}", stdout.String())
+		}
+	})
+
+	t.Run("fail, wrap with zero width", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "wrap", "-wrap-width", "0")
+		cmd.Stdin = strings.NewReader("abc")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "-wrap-width must be positive")
+	})
+
+	t.Run("ok, head_lines stops reading unlimited input", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+		defer cancel()
+		cmd = exec.CommandContext(ctx, binPath, "-quiet", "-conv", "head_lines", "-head-lines", "3", "-block-size", "4")
+		cmd.Stdin = &unlimitedReader{input: []byte("a long line\n")}
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, ctx.Err(), "process timed out")
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, strings.Repeat("a long line\n", 3), stdout.String())
+	})
+
+	t.Run("ok, head_lines and tail_lines without trailing newline", func(t *testing.T) {
+		input := "first line\nsecond line\nthird line\nlast"
+		for _, tc := range []struct {
+			args     []string
+			expected string
+		}{
+			{[]string{"-conv", "head_lines", "-head-lines", "2"}, "first line\nsecond line\n"},
+			{[]string{"-conv", "head_lines", "-head-lines", "10"}, input},
+			{[]string{"-conv", "tail_lines", "-tail-lines", "2"}, "third line\nlast"},
+			{[]string{"-conv", "tail_lines", "-tail-lines", "10"}, input},
+			{[]string{"-conv", "tail_lines", "-tail-lines", "0"}, ""},
+			{[]string{"-conv", "head_lines,tail_lines", "-head-lines", "3", "-tail-lines", "1"}, "third line\n"},
+		} {
+			for _, blockSize := range []string{"1", "3", "1024"} {
+				cmd = exec.Command(binPath, append(tc.args, "-quiet", "-block-size", blockSize)...)
+				cmd.Stdin = strings.NewReader(input)
+				stdout := &strings.Builder{}
+				cmd.Stdout = stdout
+				stderr := &strings.Builder{}
+				cmd.Stderr = stderr
+
+				err := cmd.Run()
+
+				assert.NoError(t, err)
+				assert.Zero(t, stderr.Len(), stderr.String())
+				assert.Equal(t, tc.expected, stdout.String(), tc.args)
+			}
+		}
+	})
+
+	t.Run("ok, normalize_newlines with CR at block boundaries", func(t *testing.T) {
+		for _, blockSize := range []string{"1", "2", "3", "1024"} {
+			cmd = exec.Command(binPath, "-quiet", "-conv", "normalize_newlines", "-block-size", blockSize)
+			cmd.Stdin = strings.NewReader("a\r\nb\rc\n\r\r\nd\r\re\r")
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.NoError(t, err)
+			assert.Zero(t, stderr.Len(), stderr.String())
+			assert.Equal(t, "a\nb\nc\n\n\nd\n\ne\n", stdout.String())
+		}
+	})
+
+	t.Run("ok, normalize_newlines then unix2dos gives CRLF everywhere", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "normalize_newlines,unix2dos")
+		cmd.Stdin = strings.NewReader("a\r\nb\rc\n")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+
+		assert.NoError(t, cmd.Run())
+		assert.Equal(t, "a\r\nb\r\nc\r\n", stdout.String())
+	})
+
+	t.Run("ok, block with records split between reads", func(t *testing.T) {
+		for _, blockSize := range []string{"1", "3", "1024"} {
+			cmd = exec.Command(binPath, "-quiet", "-conv", "block", "-cbs", "4", "-block-size", blockSize)
+			cmd.Stdin = strings.NewReader("ab\n\nabcdef\nабв\nxyz")
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.NoError(t, err)
+			assert.Equal(t, "2 lines truncated to -cbs 4\n", stderr.String())
+			assert.Equal(t, "ab      abcd\xd0\xb0\xd0\xb1xyz ", stdout.String())
+		}
+	})
+
+	t.Run("ok, unblock round-trips block", func(t *testing.T) {
+		for _, blockSize := range []string{"1", "3", "1024"} {
+			cmd = exec.Command(binPath, "-quiet", "-conv", "unblock", "-cbs", "4", "-block-size", blockSize)
+			cmd.Stdin = strings.NewReader("ab      abcd x y")
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.NoError(t, err)
+			assert.Zero(t, stderr.Len(), stderr.String())
+			assert.Equal(t, "ab\n\nabcd\n x y\n", stdout.String())
+		}
+	})
+
+	t.Run("fail, block without cbs", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "block")
+		cmd.Stdin = strings.NewReader("abc")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "block and unblock require a positive -cbs")
+	})
+}
+
+func TestNumberLinesReaderKeepsReadErrors(t *testing.T) {
+	errRead := errors.New("read failed")
+	reader := &NumberLinesReader{reader: &failingReader{data: []byte("a\nb"), err: errRead}}
+
+	data, err := io.ReadAll(reader)
+
+	assert.ErrorIs(t, err, errRead)
+	assert.Equal(t, "     1\ta\n     2\tb", string(data))
+}
+
+func TestSqueezeBlankReaderKeepsReadErrors(t *testing.T) {
+	errRead := errors.New("read failed")
+	reader := &SqueezeBlankReader{reader: &failingReader{data: []byte("a\n\n\nb"), err: errRead}}
+
+	data, err := io.ReadAll(reader)
+
+	assert.ErrorIs(t, err, errRead)
+	assert.Equal(t, "a\n\nb", string(data))
+}
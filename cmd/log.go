@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+type logLevel int
+
+const (
+	levelError logLevel = iota
+	levelInfo
+	levelVerbose
+)
+
+var slogLevels = map[logLevel]slog.Level{
+	levelError:   slog.LevelError,
+	levelInfo:    slog.LevelInfo,
+	levelVerbose: slog.LevelDebug,
+}
+
+type logger struct {
+	output io.Writer
+	level  logLevel
+	file   *slog.Logger
+}
+
+var diag = &logger{output: os.Stderr, level: levelInfo}
+
+func newLogger(output io.Writer, opts *Options) *logger {
+	level := levelInfo
+	switch {
+	case opts.Quiet:
+		level = levelError
+	case opts.Verbose:
+		level = levelVerbose
+	}
+	if opts.JSON {
+		output = io.Discard
+	}
+	return &logger{output: output, level: level}
+}
+
+// openFile appends every message, whatever the level, and the events to path.
+func (l *logger) openFile(path string) io.Closer {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		l.infof("warning: can not open -log-file: %v", err)
+		return nil
+	}
+	l.file = slog.New(slog.NewTextHandler(file, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	return file
+}
+
+func (l *logger) event(level slog.Level, msg string, args ...any) {
+	if l.file != nil {
+		l.file.Log(context.Background(), level, msg, args...)
+	}
+}
+
+func (l *logger) logf(level logLevel, format string, args ...any) {
+	if l.file != nil {
+		l.file.Log(context.Background(), slogLevels[level], fmt.Sprintf(format, args...))
+	}
+	if level > l.level {
+		return
+	}
+	_, _ = fmt.Fprintf(l.output, format+"\n", args...)
+}
+
+func (l *logger) errorf(format string, args ...any) {
+	l.logf(levelError, format, args...)
+}
+
+func (l *logger) infof(format string, args ...any) {
+	l.logf(levelInfo, format, args...)
+}
+
+func (l *logger) verbosef(format string, args ...any) {
+	l.logf(levelVerbose, format, args...)
+}
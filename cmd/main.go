@@ -1,26 +1,160 @@
 package main
 
 import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"log/slog"
 	"math"
 	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
 	"strings"
+	"time"
 	"unicode"
 	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/language"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
 )
 
 type Options struct {
-	From      string
+	From      []string
 	To        string
-	Offset    uint64
+	Offset    int64
 	Limit     uint64
 	BlockSize uint64
 	Conv      []string
+	GzipLevel int
+	ZstdLevel int
+
+	ASCIIFoldReplace bool
+	TabWidth         int
+	JSONEscapeASCII  bool
+	MaxSpool         uint64
+	XorKey           []byte
+	KeyFile          string
+	Key              []byte
+	BlankWhitespace  bool
+	WrapWidth        int
+	RuneMap          map[rune]rune
+	ReplacePattern   *regexp.Regexp
+	ReplaceWith      string
+	MaxLineLength    uint64
+	MatchPattern     *regexp.Regexp
+	ExcludePattern   *regexp.Regexp
+	HeadLines        uint64
+	TailLines        uint64
+	Locale           language.Tag
+	InputEncoding    encoding.Encoding
+	OutputEncoding   encoding.Encoding
+	EncodingStrict   bool
+	ConvBlockSize    uint64
+	Shift            int
+	ShiftBytes       bool
+	Append           bool
+	Force            bool
+	Seek             uint64
+	Count            uint64
+	SeekBlocks       uint64
+	Progress         bool
+	Quiet            bool
+	Verbose          bool
+	DryRun           bool
+	Version          bool
+	VersionJSON      bool
+	NoGlob           bool
+	Recursive        bool
+	FailFast         bool
+	Symlinks         string
+	Preserve         []string
+	Atomic           bool
+	Fsync            bool
+	Direct           bool
+	Sparse           bool
+	SkipIdentical    string
+	Resume           bool
+	Hash             []string
+	HashToStdout     bool
+	Verify           bool
+	Tee              []string
+	TeeBestEffort    bool
+	MaxRate          uint64
+	Timeout          time.Duration
+	Retries          int
+	RetryDelay       time.Duration
+	ErrorMap         string
+	FullBlock        bool
+	NoStdin          bool
+	IgnoreEnv        bool
+	Config           string
+	JSON             bool
+	JSONFile         string
+	LogFile          string
+	Backup           string
+	BackupSuffix     string
+	Interactive      bool
+	Mode             *os.FileMode
+	Parents          bool
+	DirMode          os.FileMode
+	CleanupOnError   bool
+	NoTrunc          bool
+	ReadBlockSize    uint64
+	WriteBlockSize   uint64
+	LimitOutput      uint64
+
+	source   *countingReader
+	input    io.Closer
+	retry    *RetryReader
+	noError  *NoErrorReader
+	reverse  *ReverseLinesReader
+	results  io.Writer
+	deadline time.Time
+}
+
+var (
+	ErrInvalidConv       = fmt.Errorf("invalid argument of -conv")
+	ErrInvalidFlag       = fmt.Errorf("invalid flag value")
+	ErrDestinationExists = fmt.Errorf("destination already exists")
+)
+
+func crossConflicts(first, second []string) [][2]string {
+	conflicts := make([][2]string, 0, len(first)*len(second))
+	for _, a := range first {
+		for _, b := range second {
+			conflicts = append(conflicts, [2]string{a, b})
+		}
+	}
+	return conflicts
 }
 
-var ErrInvalidConv = fmt.Errorf("invalid argument of -conv")
+var convConflicts = slices.Concat([][2]string{
+	{"lower_case", "upper_case"},
+	{"swap_case", "lower_case"},
+	{"swap_case", "upper_case"},
+	{"title_case", "lower_case"},
+	{"title_case", "upper_case"},
+	{"trim_spaces", "trim_left"},
+	{"trim_spaces", "trim_right"},
+	{"nfc", "nfd"},
+	{"block", "unblock"},
+	{"encrypt", "decrypt"},
+	{"ebcdic", "ibm"},
+	{"ebcdic", "ascii"},
+	{"ibm", "ascii"},
+}, crossConflicts(
+	[]string{"ebcdic", "ibm", "ascii"},
+	[]string{"lower_case", "upper_case", "swap_case", "title_case"},
+))
 
 func validatedConvs(convs string) ([]string, error) {
 	if len(convs) == 0 {
@@ -29,40 +163,436 @@ func validatedConvs(convs string) ([]string, error) {
 
 	convValues := strings.Split(convs, ",")
 	convMap := map[string]struct{}{
-		"lower_case":  {},
-		"upper_case":  {},
-		"trim_spaces": {},
+		"lower_case":         {},
+		"upper_case":         {},
+		"swap_case":          {},
+		"title_case":         {},
+		"trim_spaces":        {},
+		"trim_left":          {},
+		"trim_right":         {},
+		"squeeze_spaces":     {},
+		"nfc":                {},
+		"nfd":                {},
+		"ascii_fold":         {},
+		"dos2unix":           {},
+		"unix2dos":           {},
+		"normalize_newlines": {},
+		"expand_tabs":        {},
+		"unexpand":           {},
+		"strip_bom":          {},
+		"add_bom":            {},
+		"strip_control":      {},
+		"fix_utf8":           {},
+		"reverse_lines":      {},
+		"number_lines":       {},
+		"squeeze_blank":      {},
+		"head_lines":         {},
+		"tail_lines":         {},
+		"block":              {},
+		"unblock":            {},
+		"wrap":               {},
+		"map":                {},
+		"replace":            {},
+		"match":              {},
+		"exclude":            {},
+		"base64_encode":      {},
+		"base64_decode":      {},
+		"hex_encode":         {},
+		"hex_decode":         {},
+		"url_encode":         {},
+		"url_decode":         {},
+		"json_escape":        {},
+		"rot13":              {},
+		"shift":              {},
+		"ebcdic":             {},
+		"ibm":                {},
+		"ascii":              {},
+		"swab":               {},
+		"xor":                {},
+		"encrypt":            {},
+		"decrypt":            {},
+		"gzip":               {},
+		"gunzip":             {},
+		"zstd_encode":        {},
+		"zstd_decode":        {},
+		"bunzip2":            {},
+		"sync":               {},
+		"noerror":            {},
 	}
-	hasLower, hasUpper := false, false
+	used := make(map[string]struct{}, len(convValues))
 
 	for _, val := range convValues {
 		if _, ok := convMap[val]; !ok {
 			return nil, fmt.Errorf("%w: unknown conv %s", ErrInvalidConv, val)
-		} else if val == "lower_case" {
-			hasLower = true
-		} else if val == "upper_case" {
-			hasUpper = true
 		}
+		used[val] = struct{}{}
 	}
-	if hasLower && hasUpper {
-		return nil, fmt.Errorf("%w: lower and upper case cannot be used at the same time", ErrInvalidConv)
+	for _, pair := range convConflicts {
+		_, hasFirst := used[pair[0]]
+		_, hasSecond := used[pair[1]]
+		if hasFirst && hasSecond {
+			return nil, fmt.Errorf("%w: %s and %s cannot be used at the same time", ErrInvalidConv, pair[0], pair[1])
+		}
 	}
 
 	return convValues, nil
 }
 
+func setFlags() map[string]bool {
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	return set
+}
+
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		set = set || f.Name == name
+	})
+	return set
+}
+
+var ddOperands = map[string]string{
+	"if":    "from",
+	"of":    "to",
+	"bs":    "block-size",
+	"skip":  "skip-blocks",
+	"seek":  "seek-blocks",
+	"count": "count",
+	"conv":  "conv",
+	"iflag": "iflag",
+}
+
+func parseOperands() ([]string, error) {
+	var operands []string
+	for flag.NArg() != 0 {
+		operands = append(operands, flag.Arg(0))
+		if err := parseCommandLine(flag.Args()[1:]); err != nil {
+			return nil, err
+		}
+	}
+	return operands, nil
+}
+
+func parseCommandLine(args []string) error {
+	err := flag.CommandLine.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return fmt.Errorf("%w: %w", ErrInvalidFlag, err)
+	}
+	return err
+}
+
+var operandKey = regexp.MustCompile(`^[a-z]+$`)
+
+func applyOperands(operands []string) ([]string, error) {
+	explicit := setFlags()
+
+	var positional []string
+	for _, operand := range operands {
+		key, value, ok := strings.Cut(operand, "=")
+		if !ok || !operandKey.MatchString(key) {
+			positional = append(positional, operand)
+			continue
+		}
+		name, known := ddOperands[key]
+		if !known {
+			return nil, fmt.Errorf("%w: unknown operand %q, supported: bs=, conv=, count=, if=, iflag=, of=, seek=, skip=", ErrInvalidFlag, operand)
+		}
+		if explicit[name] {
+			continue
+		}
+		if err := flag.Set(name, value); err != nil {
+			return nil, fmt.Errorf("%w: invalid operand %q: %w", ErrInvalidFlag, operand, err)
+		}
+	}
+	return positional, nil
+}
+
 func ParseFlags() (*Options, error) {
+	origins := make(map[string]string)
+	opts, err := parseFlags(origins)
+	if err != nil {
+		return nil, withOrigin(err, origins)
+	}
+	return opts, nil
+}
+
+func parseFlags(origins map[string]string) (*Options, error) {
 	var opts Options
-	var convs string
+	var convs, xorKey, runeMap, replacePattern, matchPattern, excludePattern, locale, inputEncoding, outputEncoding, preserve, hashes, iflags, mode, dirMode string
+	var skipBlocks uint64
+	var destinations []string
 
-	flag.StringVar(&opts.From, "from", "", "file to read. by default - stdin")
-	flag.StringVar(&opts.To, "to", "", "file to write. by default - stdout")
-	flag.Uint64Var(&opts.Offset, "offset", 0, "the number of bytes, that must be skipped")
-	flag.Uint64Var(&opts.Limit, "limit", math.MaxInt, "maximum number of bytes read")
-	flag.Uint64Var(&opts.BlockSize, "block-size", 1024, "size of one block in bytes when reading and writing")
+	flag.Var((*pathsValue)(&opts.From), "from", "file to read, may be repeated to concatenate files, - for stdin, zero: for endless NUL bytes. by default - stdin")
+	flag.Var((*pathsValue)(&destinations), "to", "file to write, may be repeated to write several copies at once, - for stdout. by default - stdout")
+	flag.Var((*signedSizeValue)(&opts.Offset), "offset", "the number of bytes, that must be skipped. negative - counted from the end of -from")
+	opts.Limit = math.MaxInt
+	flag.Var((*sizeValue)(&opts.Limit), "limit", "maximum number of bytes read")
+	opts.BlockSize = 1024
+	flag.Var((*sizeValue)(&opts.BlockSize), "block-size", "size of one block in bytes when reading and writing")
 	flag.StringVar(&convs, "conv", "", "one or more of the possible transformations on the text")
+	flag.IntVar(&opts.GzipLevel, "gzip-level", gzip.DefaultCompression, "compression level for -conv gzip, from 1 to 9")
+	flag.IntVar(&opts.ZstdLevel, "zstd-level", 3, "compression level for -conv zstd_encode, from 1 to 22")
+	flag.BoolVar(&opts.ASCIIFoldReplace, "ascii-fold-replace", false, "replace runes without ascii equivalent with '?' for -conv ascii_fold")
+	flag.IntVar(&opts.TabWidth, "tab-width", 8, "distance between tab stops for -conv expand_tabs and unexpand")
+	flag.BoolVar(&opts.JSONEscapeASCII, "json-escape-ascii", false, "escape every non-ascii rune as \\uXXXX for -conv json_escape")
+	flag.Uint64Var(&opts.MaxSpool, "max-spool", 1<<30, "maximum number of bytes spooled to a temporary file by -conv reverse_lines")
+	flag.StringVar(&xorKey, "xor-key", "", "key in hex for -conv xor, e.g. deadbeef")
+	flag.StringVar(&opts.KeyFile, "key-file", "", "file with a raw 32-byte AES-256 key for -conv encrypt and decrypt")
+	flag.BoolVar(&opts.BlankWhitespace, "blank-whitespace", false, "treat whitespace-only lines as empty for -conv squeeze_blank")
+	flag.IntVar(&opts.WrapWidth, "wrap-width", 80, "maximum number of runes in a line for -conv wrap")
+	flag.StringVar(&runeMap, "map", "", "runes to translate for -conv map as FROM:TO, e.g. абв:abv")
+	flag.StringVar(&replacePattern, "replace-pattern", "", "RE2 regular expression for -conv replace")
+	flag.StringVar(&opts.ReplaceWith, "replace-with", "", "replacement for -conv replace, may reference groups as $1")
+	flag.Uint64Var(&opts.MaxLineLength, "max-line-length", 1<<20, "maximum length of a line in bytes buffered by -conv replace, match and exclude")
+	flag.StringVar(&matchPattern, "match", "", "RE2 regular expression selecting lines for -conv match")
+	flag.StringVar(&excludePattern, "exclude-pattern", "", "RE2 regular expression dropping lines for -conv exclude")
+	flag.Uint64Var(&opts.HeadLines, "head-lines", 10, "number of first lines kept by -conv head_lines")
+	flag.Uint64Var(&opts.TailLines, "tail-lines", 10, "number of last lines kept by -conv tail_lines")
+	flag.StringVar(&locale, "locale", "", "language of -conv lower_case and upper_case, one of "+strings.Join(supportedLocales, ", "))
+	flag.StringVar(&inputEncoding, "input-encoding", "", "charset of the input, decoded to UTF-8 before -conv, e.g. windows-1251")
+	flag.StringVar(&outputEncoding, "output-encoding", "", "charset of the output, encoded from UTF-8 after -conv, e.g. koi8-r")
+	flag.BoolVar(&opts.EncodingStrict, "encoding-strict", false, "fail on runes missing in -output-encoding instead of writing '?'")
+	flag.Uint64Var(&opts.ConvBlockSize, "cbs", 0, "record size in bytes for -conv block and unblock")
+	flag.IntVar(&opts.Shift, "shift", 0, "number of positions to rotate letters by for -conv shift, may be negative")
+	flag.BoolVar(&opts.ShiftBytes, "shift-bytes", false, "rotate all 256 byte values instead of ascii letters for -conv shift")
+	flag.BoolVar(&opts.Append, "append", false, "append to the end of -to instead of creating a new file")
+	flag.BoolVar(&opts.Force, "force", false, "truncate and overwrite -to if it already exists")
+	flag.Uint64Var(&opts.Seek, "seek", 0, "the number of bytes, that must be skipped in -to before writing, without truncating it")
+	flag.Uint64Var(&opts.Count, "count", math.MaxInt, "maximum number of blocks of -block-size bytes copied")
+	flag.Uint64Var(&skipBlocks, "skip-blocks", 0, "the number of blocks of -block-size bytes, that must be skipped in the input")
+	flag.Uint64Var(&opts.SeekBlocks, "seek-blocks", 0, "the number of blocks of -block-size bytes, that must be skipped in the output")
+	flag.BoolVar(&opts.Progress, "progress", false, "print bytes copied and throughput on stderr every second")
+	flag.BoolVar(&opts.Quiet, "quiet", false, "print nothing on stderr except errors")
+	flag.BoolVar(&opts.Verbose, "verbose", false, "log every stage of the copy on stderr")
+	flag.BoolVar(&opts.DryRun, "dry-run", false, "check the source and destination and print what would be copied without writing anything")
+	flag.BoolVar(&opts.Version, "version", false, "print the version, git commit and go version and exit")
+	flag.BoolVar(&opts.VersionJSON, "version-json", false, "print the version as a json object and exit")
+	flag.BoolVar(&opts.NoGlob, "no-glob", false, "open -from literally instead of expanding *, ? and [ patterns")
+	flag.BoolVar(&opts.Recursive, "recursive", false, "copy the directory in -from with all its files into -to")
+	flag.BoolVar(&opts.FailFast, "fail-fast", false, "stop -recursive at the first file that fails to copy")
+	flag.StringVar(&opts.Symlinks, "symlinks", "", "what to do with symlinks in -from: follow, skip or copy. by default - follow, skip for -recursive")
+	flag.StringVar(&preserve, "preserve", "", "comma separated attributes of -from applied to -to after the copy: mode, times")
+	flag.BoolVar(&opts.Atomic, "atomic", false, "write to a temporary file next to -to and rename it over -to only after a successful copy")
+	flag.BoolVar(&opts.Fsync, "fsync", false, "flush -to to the disk before reporting success")
+	flag.BoolVar(&opts.Direct, "direct", false, "bypass the page cache with O_DIRECT for -from and -to files (linux only)")
+	flag.BoolVar(&opts.Sparse, "sparse", false, "seek over blocks of zeros in -to instead of writing them, leaving holes")
+	flag.Var((*skipIdenticalValue)(&opts.SkipIdentical), "skip-identical", "do not copy when -to already has the content of -from, compared by sha256 or, with =size, by size only")
+	flag.BoolVar(&opts.Resume, "resume", false, "continue an interrupted copy from the current size of -to")
+	flag.StringVar(&hashes, "hash", "", "comma separated checksums of the written bytes printed after the copy: md5, sha1, sha256, sha512")
+	flag.BoolVar(&opts.HashToStdout, "hash-to-stdout", false, "print -hash on stdout instead of stderr, requires -to")
+	flag.BoolVar(&opts.Verify, "verify", false, "read -to back after the copy and compare it with the written bytes")
+	flag.BoolVar(&opts.TeeBestEffort, "tee-best-effort", false, "keep writing to the other -to when one of several fails")
+	flag.Var((*sizeValue)(&opts.MaxRate), "max-rate", "maximum average number of bytes read per second, e.g. 10M")
+	flag.DurationVar(&opts.Timeout, "timeout", 0, "abort the copy when it takes longer than this, e.g. 30s or 5m")
+	flag.IntVar(&opts.Retries, "retries", 0, "number of times to retry a read of -from failing with EIO or ESTALE")
+	flag.DurationVar(&opts.RetryDelay, "retry-delay", time.Second, "delay before the first -retries attempt, doubled on every next one")
+	flag.StringVar(&opts.ErrorMap, "error-map", "", "file to list the offsets of blocks skipped by -conv noerror in instead of the summary")
+	flag.StringVar(&iflags, "iflag", "", "comma separated input flags: fullblock - keep reading until every block is full")
+	flag.BoolVar(&opts.NoStdin, "no-stdin", false, "fail instead of waiting for input when stdin is a terminal")
+	flag.BoolVar(&opts.IgnoreEnv, "ignore-env", false, "do not take flag defaults from "+envPrefix+"* environment variables")
+	flag.StringVar(&opts.Config, "config", "", "yaml or json file with flag values, keys are flag names with _ instead of -")
+	flag.BoolVar(&opts.JSON, "json", false, "print a json object with the result of the copy to stderr instead of the usual messages")
+	flag.StringVar(&opts.JSONFile, "json-file", "", "write the -json result to this file instead of stderr, implies -json")
+	flag.StringVar(&opts.LogFile, "log-file", "", "append timestamped key=value log lines of the copy to this file")
+	flag.Var((*backupValue)(&opts.Backup), "backup", "rename an existing -to before overwriting it, adding -backup-suffix or, with =numbered, .~N~")
+	flag.StringVar(&opts.BackupSuffix, "backup-suffix", "~", "suffix of -backup copies")
+	flag.BoolVar(&opts.Interactive, "interactive", false, "ask on the terminal before overwriting an existing -to, unless -force is given")
+	flag.StringVar(&mode, "mode", "", "octal permissions of -to, e.g. 0600, set regardless of the umask")
+	flag.BoolVar(&opts.Parents, "parents", false, "create missing parent directories of -to")
+	flag.StringVar(&dirMode, "dir-mode", "0755", "octal permissions of directories created by -parents and -recursive, reduced by the umask")
+	flag.BoolVar(&opts.CleanupOnError, "cleanup-on-error", false, "remove -to created by this copy when the copy fails, files that existed before are kept")
+	flag.BoolVar(&opts.NoTrunc, "notrunc", false, "keep the bytes of an existing -to after the written ones. by default - true with -seek or -seek-blocks, false otherwise")
+	flag.Var((*sizeValue)(&opts.ReadBlockSize), "read-block-size", "size of one block in bytes when reading, overrides -block-size")
+	flag.Var((*sizeValue)(&opts.WriteBlockSize), "write-block-size", "size of one block in bytes when writing, overrides -block-size")
+	opts.LimitOutput = math.MaxInt
+	flag.Var((*sizeValue)(&opts.LimitOutput), "limit-output", "maximum number of bytes written after -conv, never splitting a rune of text convs")
+	flag.Usage = printUsage
+
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+	if err := parseCommandLine(os.Args[1:]); err != nil {
+		return nil, err
+	}
+	if opts.Version || opts.VersionJSON {
+		return &opts, nil
+	}
+	operands, err := parseOperands()
+	if err != nil {
+		return nil, err
+	}
+	positional, err := applyOperands(operands)
+	if err != nil {
+		return nil, err
+	}
+	if err = applyPositional(positional); err != nil {
+		return nil, err
+	}
+	if opts.Config != "" {
+		if err := applyConfig(opts.Config, origins); err != nil {
+			return nil, err
+		}
+	}
+	if !opts.IgnoreEnv {
+		if err := applyEnvironment(origins); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(opts.From) == 1 && opts.From[0] == "-" {
+		opts.From = nil
+	}
+	if slices.Contains(opts.From, "-") {
+		return nil, fmt.Errorf("%w: - (stdin) must be the only -from, use ./- for a file named -", ErrInvalidFlag)
+	}
+	if len(destinations) != 0 {
+		opts.To, opts.Tee = destinations[0], destinations[1:]
+	}
+	if len(opts.Tee) == 0 && opts.To == "-" {
+		opts.To = ""
+	}
+	if len(opts.Tee) != 0 {
+		if opts.To == "-" {
+			opts.To = ""
+		}
+		for i, path := range opts.Tee {
+			if path == "-" {
+				opts.Tee[i] = ""
+			}
+		}
+		if opts.Recursive || opts.Direct || opts.Sparse || opts.Resume || opts.SkipIdentical != "" ||
+			opts.Verify || len(preserve) != 0 || opts.Fsync {
+			return nil, fmt.Errorf("%w: several -to cannot be used with -recursive, -direct, -sparse, -resume, -skip-identical, -verify, -preserve or -fsync", ErrInvalidFlag)
+		}
+	}
+
+	if !opts.NoGlob {
+		var err error
+		opts.From, err = expandGlobs(opts.From)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.GzipLevel != gzip.DefaultCompression &&
+		(opts.GzipLevel < gzip.BestSpeed || opts.GzipLevel > gzip.BestCompression) {
+		return nil, fmt.Errorf("%w: -gzip-level must be from 1 to 9, got %d", ErrInvalidFlag, opts.GzipLevel)
+	}
+	if opts.Quiet && opts.Verbose {
+		return nil, fmt.Errorf("%w: -quiet and -verbose cannot be used at the same time", ErrInvalidFlag)
+	}
+	if opts.BlockSize == 0 {
+		return nil, fmt.Errorf("%w: -block-size must be positive", ErrInvalidFlag)
+	}
+	if isFlagSet("read-block-size") && opts.ReadBlockSize == 0 || isFlagSet("write-block-size") && opts.WriteBlockSize == 0 {
+		return nil, fmt.Errorf("%w: -read-block-size and -write-block-size must be positive", ErrInvalidFlag)
+	}
+	if opts.ReadBlockSize == 0 {
+		opts.ReadBlockSize = opts.BlockSize
+	}
+	if opts.WriteBlockSize == 0 {
+		opts.WriteBlockSize = opts.BlockSize
+	}
+	if opts.ZstdLevel < 1 || opts.ZstdLevel > 22 {
+		return nil, fmt.Errorf("%w: -zstd-level must be from 1 to 22, got %d", ErrInvalidFlag, opts.ZstdLevel)
+	}
+	if opts.TabWidth < 1 {
+		return nil, fmt.Errorf("%w: -tab-width must be positive, got %d", ErrInvalidFlag, opts.TabWidth)
+	}
+	if opts.WrapWidth < 1 {
+		return nil, fmt.Errorf("%w: -wrap-width must be positive, got %d", ErrInvalidFlag, opts.WrapWidth)
+	}
+	if opts.Offset < 0 && (len(opts.From) == 0 || slices.Contains(opts.Conv, "bunzip2")) {
+		return nil, fmt.Errorf("%w: negative -offset requires a regular file in -from and no bunzip2", ErrInvalidFlag)
+	}
+	if opts.Offset < 0 && len(opts.From) > 1 {
+		return nil, fmt.Errorf("%w: negative -offset cannot be used with several -from", ErrInvalidFlag)
+	}
+	if isFlagSet("count") && isFlagSet("limit") {
+		return nil, fmt.Errorf("%w: -count and -limit cannot be used at the same time", ErrInvalidFlag)
+	}
+	if isFlagSet("skip-blocks") {
+		if isFlagSet("offset") {
+			return nil, fmt.Errorf("%w: -skip-blocks and -offset cannot be used at the same time", ErrInvalidFlag)
+		}
+		if skipBlocks > math.MaxInt64/opts.ReadBlockSize {
+			return nil, fmt.Errorf("%w: -skip-blocks %d of -read-block-size %d overflows", ErrInvalidFlag, skipBlocks, opts.ReadBlockSize)
+		}
+		opts.Offset = int64(skipBlocks * opts.ReadBlockSize)
+	}
+	if isFlagSet("seek-blocks") {
+		if isFlagSet("seek") {
+			return nil, fmt.Errorf("%w: -seek-blocks and -seek cannot be used at the same time", ErrInvalidFlag)
+		}
+		if opts.SeekBlocks > math.MaxInt64/opts.WriteBlockSize {
+			return nil, fmt.Errorf("%w: -seek-blocks %d of -write-block-size %d overflows", ErrInvalidFlag, opts.SeekBlocks, opts.WriteBlockSize)
+		}
+		if opts.Append {
+			return nil, fmt.Errorf("%w: -seek-blocks and -append cannot be used at the same time", ErrInvalidFlag)
+		}
+	}
+	switch {
+	case opts.Symlinks == "" && opts.Recursive:
+		opts.Symlinks = "skip"
+	case opts.Symlinks == "":
+		opts.Symlinks = "follow"
+	case !slices.Contains(symlinkPolicies, opts.Symlinks):
+		return nil, fmt.Errorf("%w: unknown -symlinks %q, supported: %s", ErrInvalidFlag, opts.Symlinks, strings.Join(symlinkPolicies, ", "))
+	}
+	if opts.Recursive {
+		if len(opts.From) != 1 || opts.To == "" {
+			return nil, fmt.Errorf("%w: -recursive requires a single -from and a -to", ErrInvalidFlag)
+		}
+		if opts.DryRun {
+			return nil, fmt.Errorf("%w: -recursive and -dry-run cannot be used at the same time", ErrInvalidFlag)
+		}
+	}
+	if opts.Atomic {
+		if opts.To == "" {
+			return nil, fmt.Errorf("%w: -atomic requires -to", ErrInvalidFlag)
+		}
+		if opts.Append || opts.Seek != 0 || opts.SeekBlocks != 0 {
+			return nil, fmt.Errorf("%w: -atomic cannot be used with -append, -seek or -seek-blocks", ErrInvalidFlag)
+		}
+	}
+	if opts.Direct {
+		if !directSupported {
+			return nil, fmt.Errorf("%w: -direct is not supported on %s", ErrInvalidFlag, runtime.GOOS)
+		}
+		for _, size := range []uint64{opts.ReadBlockSize, opts.WriteBlockSize} {
+			if size%directAlignment != 0 {
+				return nil, fmt.Errorf("%w: -direct requires -block-size to be a multiple of the logical sector size %d, got %d", ErrInvalidFlag, directAlignment, size)
+			}
+		}
+		if len(opts.From) > 1 || opts.Append || opts.Seek != 0 || opts.SeekBlocks != 0 || opts.Atomic {
+			return nil, fmt.Errorf("%w: -direct cannot be used with several -from, -append, -seek, -seek-blocks or -atomic", ErrInvalidFlag)
+		}
+	}
+	if opts.Sparse && (opts.Append || opts.Seek != 0 || opts.SeekBlocks != 0 || opts.Direct) {
+		return nil, fmt.Errorf("%w: -sparse cannot be used with -append, -seek, -seek-blocks or -direct", ErrInvalidFlag)
+	}
+	if !isFlagSet("notrunc") {
+		opts.NoTrunc = opts.Seek != 0 || opts.SeekBlocks != 0
+	} else if opts.NoTrunc && (opts.Atomic || opts.Sparse) {
+		return nil, fmt.Errorf("%w: -notrunc cannot be used with -atomic or -sparse", ErrInvalidFlag)
+	}
+	if opts.Append && opts.To == "" {
+		return nil, fmt.Errorf("%w: -append requires -to", ErrInvalidFlag)
+	}
+	if opts.Seek != 0 && opts.To == "" {
+		return nil, fmt.Errorf("%w: -seek requires a seekable -to, stdout is not", ErrInvalidFlag)
+	}
+	if opts.Seek != 0 && opts.Append {
+		return nil, fmt.Errorf("%w: -seek and -append cannot be used at the same time", ErrInvalidFlag)
+	}
 
-	flag.Parse()
+	if locale != "" {
+		if !slices.Contains(supportedLocales, locale) {
+			return nil, fmt.Errorf("%w: unknown -locale %q, supported: %s", ErrInvalidFlag, locale, strings.Join(supportedLocales, ", "))
+		}
+		opts.Locale = language.Make(locale)
+	}
 
 	convValues, err := validatedConvs(convs)
 	if err != nil {
@@ -70,6 +600,177 @@ func ParseFlags() (*Options, error) {
 	}
 	opts.Conv = convValues
 
+	opts.Preserve, err = parsePreserve(preserve)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.Hash, err = parseHashes(hashes)
+	if err != nil {
+		return nil, err
+	}
+	if opts.HashToStdout && (opts.To == "" || slices.Contains(opts.Tee, "")) {
+		return nil, fmt.Errorf("%w: -hash-to-stdout requires -to, stdout already carries the data", ErrInvalidFlag)
+	}
+	if opts.Verify && opts.To == "" {
+		return nil, fmt.Errorf("%w: -verify requires -to, stdout can not be read back", ErrInvalidFlag)
+	}
+
+	if opts.SkipIdentical != "" && (len(opts.Conv) != 0 || opts.Offset != 0 || isFlagSet("limit") || isFlagSet("count") ||
+		opts.Append || opts.Seek != 0 || opts.SeekBlocks != 0) {
+		return nil, fmt.Errorf("%w: -skip-identical compares whole files and cannot be used with -conv, -offset, -limit, -count, -append, -seek or -seek-blocks", ErrInvalidFlag)
+	}
+	if opts.Resume {
+		if len(opts.From) != 1 || opts.To == "" {
+			return nil, fmt.Errorf("%w: -resume requires a single -from and a -to", ErrInvalidFlag)
+		}
+		if len(opts.Conv) != 0 {
+			return nil, fmt.Errorf("%w: -resume cannot be used with -conv, byte positions of -from and -to would not match", ErrInvalidFlag)
+		}
+		if opts.Offset < 0 || isFlagSet("count") || opts.Append || opts.Seek != 0 || opts.SeekBlocks != 0 ||
+			opts.Atomic || opts.Direct || opts.Sparse {
+			return nil, fmt.Errorf("%w: -resume cannot be used with negative -offset, -count, -append, -seek, -seek-blocks, -atomic, -direct or -sparse", ErrInvalidFlag)
+		}
+	}
+
+	if err = checkInfiniteSource(&opts); err != nil {
+		return nil, err
+	}
+
+	opts.InputEncoding, err = lookupCharset("-input-encoding", inputEncoding)
+	if err != nil {
+		return nil, err
+	}
+	opts.OutputEncoding, err = lookupCharset("-output-encoding", outputEncoding)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.XorKey, err = hex.DecodeString(xorKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid -xor-key %q: %w", ErrInvalidConv, xorKey, err)
+	}
+	if slices.Contains(opts.Conv, "xor") && len(opts.XorKey) == 0 {
+		return nil, fmt.Errorf("%w: xor requires a non-empty -xor-key", ErrInvalidConv)
+	}
+
+	if slices.Contains(opts.Conv, "map") {
+		if runeMap == "" {
+			return nil, fmt.Errorf("%w: map requires -map", ErrInvalidConv)
+		}
+		opts.RuneMap, err = parseRuneMap(runeMap)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if slices.Contains(opts.Conv, "replace") {
+		if replacePattern == "" {
+			return nil, fmt.Errorf("%w: replace requires -replace-pattern", ErrInvalidConv)
+		}
+		opts.ReplacePattern, err = regexp.Compile(replacePattern)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid -replace-pattern: %w", ErrInvalidConv, err)
+		}
+	}
+
+	if slices.Contains(opts.Conv, "match") {
+		if matchPattern == "" {
+			return nil, fmt.Errorf("%w: match requires -match", ErrInvalidConv)
+		}
+		opts.MatchPattern, err = regexp.Compile(matchPattern)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid -match: %w", ErrInvalidConv, err)
+		}
+	}
+
+	if slices.Contains(opts.Conv, "exclude") {
+		if excludePattern == "" {
+			return nil, fmt.Errorf("%w: exclude requires a non-empty -exclude-pattern", ErrInvalidConv)
+		}
+		opts.ExcludePattern, err = regexp.Compile(excludePattern)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid -exclude-pattern: %w", ErrInvalidConv, err)
+		}
+	}
+
+	if (slices.Contains(opts.Conv, "block") || slices.Contains(opts.Conv, "unblock")) && opts.ConvBlockSize == 0 {
+		return nil, fmt.Errorf("%w: block and unblock require a positive -cbs", ErrInvalidConv)
+	}
+
+	if slices.Contains(opts.Conv, "shift") && !isFlagSet("shift") {
+		return nil, fmt.Errorf("%w: shift requires -shift", ErrInvalidConv)
+	}
+
+	if slices.Contains(opts.Conv, "encrypt") || slices.Contains(opts.Conv, "decrypt") {
+		if opts.KeyFile == "" {
+			return nil, fmt.Errorf("%w: encrypt and decrypt require -key-file", ErrInvalidConv)
+		}
+		opts.Key, err = os.ReadFile(opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: can not read -key-file: %w", ErrInvalidConv, err)
+		}
+		if len(opts.Key) != aesKeySize {
+			return nil, fmt.Errorf("%w: -key-file must contain exactly %d bytes, got %d", ErrInvalidConv, aesKeySize, len(opts.Key))
+		}
+	}
+
+	if opts.Timeout < 0 {
+		return nil, fmt.Errorf("%w: -timeout must not be negative", ErrInvalidFlag)
+	}
+	if opts.JSONFile != "" {
+		opts.JSON = true
+	}
+	if opts.BackupSuffix == "" || strings.ContainsRune(opts.BackupSuffix, filepath.Separator) {
+		return nil, fmt.Errorf("%w: -backup-suffix must be a non-empty file name suffix", ErrInvalidFlag)
+	}
+
+	if opts.Retries < 0 || opts.RetryDelay < 0 {
+		return nil, fmt.Errorf("%w: -retries and -retry-delay must not be negative", ErrInvalidFlag)
+	}
+	if opts.Retries != 0 && (len(opts.From) > 1 || opts.Direct) {
+		return nil, fmt.Errorf("%w: -retries cannot be used with several -from or -direct", ErrInvalidFlag)
+	}
+
+	if slices.Contains(opts.Conv, "noerror") && (len(opts.From) > 1 || opts.Direct) {
+		return nil, fmt.Errorf("%w: noerror cannot be used with several -from or -direct", ErrInvalidConv)
+	}
+	if opts.ErrorMap != "" && !slices.Contains(opts.Conv, "noerror") {
+		return nil, fmt.Errorf("%w: -error-map requires -conv noerror", ErrInvalidFlag)
+	}
+
+	opts.Mode, err = parseMode("mode", mode)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Mode != nil {
+		if opts.To == "" && !slices.ContainsFunc(opts.Tee, func(path string) bool { return path != "" }) {
+			return nil, fmt.Errorf("%w: -mode requires -to, permissions of stdout can not be set", ErrInvalidFlag)
+		}
+		if slices.Contains(opts.Preserve, "mode") {
+			return nil, fmt.Errorf("%w: -mode and -preserve mode cannot be used at the same time", ErrInvalidFlag)
+		}
+	}
+
+	parsedDirMode, err := parseMode("dir-mode", dirMode)
+	if err != nil {
+		return nil, err
+	}
+	opts.DirMode = *parsedDirMode
+	if isFlagSet("dir-mode") && !opts.Parents && !opts.Recursive {
+		return nil, fmt.Errorf("%w: -dir-mode requires -parents or -recursive", ErrInvalidFlag)
+	}
+
+	for _, iflag := range strings.Split(iflags, ",") {
+		switch iflag {
+		case "":
+		case "fullblock":
+			opts.FullBlock = true
+		default:
+			return nil, fmt.Errorf("%w: unknown -iflag %s, supported: fullblock", ErrInvalidFlag, iflag)
+		}
+	}
+
 	return &opts, nil
 }
 
@@ -80,11 +781,86 @@ func copyFromChecked(dst, src []byte) ([]byte, int) {
 	return src, length
 }
 
+type caseMode int
+
+const (
+	lowerCase caseMode = iota
+	upperCase
+	swapCase
+	titleCase
+)
+
+var supportedLocales = []string{"az", "el", "lt", "nl", "tr"}
+
+func newCaseReader(reader io.Reader, mode caseMode, locale language.Tag) io.Reader {
+	if locale == language.Und {
+		return &CaseReader{reader: reader, mode: mode}
+	}
+	if mode == lowerCase {
+		return transform.NewReader(reader, cases.Lower(locale))
+	}
+	return transform.NewReader(reader, cases.Upper(locale))
+}
+
 type CaseReader struct {
-	reader  io.Reader
-	toUpper bool
-	mapped  []byte
-	buffer  []byte
+	reader io.Reader
+	mode   caseMode
+	mapped []byte
+	buffer []byte
+	inWord bool
+}
+
+func swapRuneCase(r rune) rune {
+	switch {
+	case unicode.IsUpper(r):
+		return unicode.ToLower(r)
+	case unicode.IsLower(r):
+		return unicode.ToUpper(r)
+	}
+	return r
+}
+
+var asciiCaseTables = func() map[caseMode]*[256]byte {
+	tables := map[caseMode]*[256]byte{lowerCase: {}, upperCase: {}, swapCase: {}}
+	for i := range utf8.RuneSelf {
+		r := rune(i)
+		tables[lowerCase][i] = byte(unicode.ToLower(r))
+		tables[upperCase][i] = byte(unicode.ToUpper(r))
+		tables[swapCase][i] = byte(swapRuneCase(r))
+	}
+	return tables
+}()
+
+func isASCII(data []byte) bool {
+	for _, b := range data {
+		if b >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+func (cr *CaseReader) mapASCII(data []byte) {
+	if cr.mode != titleCase {
+		table := asciiCaseTables[cr.mode]
+		for _, b := range data {
+			cr.mapped = append(cr.mapped, table[b])
+		}
+		return
+	}
+
+	for _, b := range data {
+		switch {
+		case !(b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'):
+			cr.inWord = false
+		case cr.inWord:
+			b = asciiCaseTables[lowerCase][b]
+		default:
+			b = asciiCaseTables[upperCase][b]
+			cr.inWord = true
+		}
+		cr.mapped = append(cr.mapped, b)
+	}
 }
 
 func (cr *CaseReader) Read(p []byte) (n int, err error) {
@@ -95,8 +871,15 @@ func (cr *CaseReader) Read(p []byte) (n int, err error) {
 
 	buffer := make([]byte, len(p))
 	n, err = cr.reader.Read(buffer)
-	if err != nil {
-		return n, err
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+	if len(cr.buffer) == 0 && isASCII(buffer[:n]) {
+		cr.mapASCII(buffer[:n])
+		if errors.Is(err, io.EOF) && len(cr.mapped) == 0 {
+			return 0, io.EOF
+		}
+		return cr.Read(p)
 	}
 	cr.buffer = append(cr.buffer, buffer[:n]...)
 
@@ -108,19 +891,38 @@ func (cr *CaseReader) Read(p []byte) (n int, err error) {
 			break
 		}
 
-		if cr.toUpper {
+		switch cr.mode {
+		case upperCase:
 			cr.mapped = append(cr.mapped, []byte(strings.ToUpper(string(r)))...)
-		} else {
+		case lowerCase:
 			cr.mapped = append(cr.mapped, []byte(strings.ToLower(string(r)))...)
+		case swapCase:
+			cr.mapped = utf8.AppendRune(cr.mapped, swapRuneCase(r))
+		case titleCase:
+			switch {
+			case !unicode.IsLetter(r):
+				cr.inWord = false
+			case cr.inWord:
+				r = unicode.ToLower(r)
+			default:
+				r = unicode.ToTitle(r)
+				cr.inWord = true
+			}
+			cr.mapped = utf8.AppendRune(cr.mapped, r)
 		}
 	}
 
 	cr.buffer = cr.buffer[i:]
+	if errors.Is(err, io.EOF) && len(cr.mapped) == 0 {
+		return 0, io.EOF
+	}
 	return cr.Read(p)
 }
 
 type TrimReader struct {
 	reader        io.Reader
+	trimLeft      bool
+	trimRight     bool
 	buffer        []byte
 	trimmed       []byte
 	skippedSpaces bool
@@ -134,8 +936,8 @@ func (tr *TrimReader) Read(p []byte) (n int, err error) {
 
 	buffer := make([]byte, len(p))
 	n, err = tr.reader.Read(buffer)
-	if err != nil {
-		return n, err
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
 	}
 	tr.buffer = append(tr.buffer, buffer[:n]...)
 
@@ -147,97 +949,797 @@ func (tr *TrimReader) Read(p []byte) (n int, err error) {
 			break
 		}
 
-		if unicode.IsSpace(r) {
+		if unicode.IsSpace(r) && (tr.trimRight || tr.trimLeft && !tr.skippedSpaces) {
 			continue
 		}
 
-		if tr.skippedSpaces {
+		if tr.skippedSpaces || !tr.trimLeft {
 			tr.trimmed = append(tr.trimmed, tr.buffer[firstSpacePos:i+runeSize]...)
 		} else {
 			tr.trimmed = append(tr.trimmed, tr.buffer[i:i+runeSize]...)
-			tr.skippedSpaces = true
 		}
+		tr.skippedSpaces = true
 		firstSpacePos = i + runeSize
 	}
 
 	tr.buffer = tr.buffer[firstSpacePos:]
+	if errors.Is(err, io.EOF) && len(tr.trimmed) == 0 {
+		return 0, io.EOF
+	}
 	return tr.Read(p)
 }
 
+func seekFromEnd(file *os.File, offset int64) (int64, error) {
+	stat, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if !stat.Mode().IsRegular() {
+		return 0, fmt.Errorf("%w: negative -offset requires a regular file in -from", ErrInvalidFlag)
+	}
+
+	position, err := file.Seek(max(stat.Size()+offset, 0), io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+	diag.verbosef("skipped %d bytes of input via seek from the end", position)
+	return position, nil
+}
+
+func skipInput(reader io.Reader, file *os.File, offset int64) error {
+	if seeker, ok := reader.(io.Seeker); ok && file != nil {
+		if stat, err := file.Stat(); err == nil && stat.Mode().IsRegular() {
+			if offset > stat.Size() {
+				return fmt.Errorf("can not skip %d bytes of -offset: input ended after %d", offset, stat.Size())
+			}
+			_, err = seeker.Seek(offset, io.SeekStart)
+			if err == nil && offset != 0 {
+				diag.verbosef("skipped %d bytes of input via seek", offset)
+			}
+			return err
+		}
+	}
+
+	n, err := io.CopyN(io.Discard, reader, offset)
+	if errors.Is(err, io.EOF) {
+		return fmt.Errorf("can not skip %d bytes of -offset: input ended after %d", offset, n)
+	}
+	if err != nil {
+		return err
+	}
+	if offset != 0 {
+		diag.verbosef("skipped %d bytes of input via read", offset)
+	}
+	return nil
+}
+
+func sourceSize(opts *Options) int64 {
+	if len(opts.From) == 0 || slices.Contains(opts.Conv, "bunzip2") {
+		return 0
+	}
+	if isInfiniteSource(opts.From[0]) {
+		if isFlagSet("count") && opts.Count < math.MaxInt64/opts.ReadBlockSize {
+			return int64(opts.Count * opts.ReadBlockSize)
+		}
+		return int64(opts.Limit)
+	}
+	var total int64
+	for _, path := range opts.From {
+		stat, err := os.Stat(path)
+		if err != nil || !stat.Mode().IsRegular() {
+			return 0
+		}
+		total += stat.Size()
+	}
+	return min(max(total-opts.Offset, 0), int64(opts.Limit))
+}
+
 func CreateReader(opts *Options) (io.Reader, error) {
 	var reader io.Reader
+	var file *os.File
 	var err error
 
-	if opts.From == "" {
+	if len(opts.From) == 0 {
+		if isTerminal(os.Stdin) {
+			if opts.NoStdin {
+				return nil, fmt.Errorf("stdin is a terminal, pass -from or pipe the input")
+			}
+			diag.infof("reading from terminal; press Ctrl-D to end input or pass -from")
+		}
 		reader = os.Stdin
+		diag.verbosef("reading from stdin")
+	} else if isInfiniteSource(opts.From[0]) {
+		reader = ZeroReader{}
+		diag.verbosef("reading NUL bytes from %s", opts.From[0])
 	} else {
-		reader, err = os.Open(opts.From)
+		file, err = os.OpenFile(opts.From[0], os.O_RDONLY|directFlag(opts), 0)
 		if err != nil {
 			return nil, err
 		}
+		reader, opts.input = file, file
+		if opts.Direct {
+			reader = &DirectReader{file: file}
+		}
+		diag.verbosef("opened source %s", opts.From[0])
+		if len(opts.From) > 1 {
+			concat := &ConcatReader{current: file, paths: opts.From[1:]}
+			reader, opts.input = concat, concat
+			file = nil
+		}
 	}
 
-	n, err := io.CopyN(io.Discard, reader, int64(opts.Offset))
-	if err != nil {
-		return nil, err
+	if opts.Retries != 0 {
+		path := ""
+		if file != nil {
+			path = file.Name()
+		}
+		opts.retry = newRetryReader(reader, path, opts.Retries, opts.RetryDelay)
+		reader = opts.retry
+		if file != nil {
+			opts.input = opts.retry
+		}
+		diag.verbosef("retrying failed reads up to %d times", opts.Retries)
+	}
+	if slices.Contains(opts.Conv, "noerror") {
+		opts.noError = &NoErrorReader{reader: reader, blockSize: int(opts.ReadBlockSize), sync: slices.Contains(opts.Conv, "sync")}
+		reader = opts.noError
+	}
+
+	if slices.Contains(opts.Conv, "bunzip2") {
+		reader = &Bunzip2Reader{reader: reader}
+		diag.verbosef("added conv bunzip2")
 	}
-	if n < int64(opts.Offset) {
-		return nil, fmt.Errorf("error while skipping bytes")
+
+	if opts.Offset < 0 {
+		opts.Offset, err = seekFromEnd(file, opts.Offset)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		err = skipInput(reader, file, opts.Offset)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	reader = io.LimitReader(reader, int64(opts.Limit))
+	if isFlagSet("limit") {
+		diag.verbosef("limited input to %d bytes", opts.Limit)
+	}
+	opts.source = &countingReader{reader: reader, total: sourceSize(opts)}
+	reader = opts.source
+	if opts.MaxRate != 0 {
+		reader = newRateLimitReader(reader, opts.MaxRate, opts.ReadBlockSize)
+		diag.verbosef("limited reading to %d bytes per second", opts.MaxRate)
+	}
 
+	decodeAt, encodeAt := charsetBounds(opts.Conv)
 	if len(opts.Conv) != 0 {
-		for _, val := range opts.Conv {
+		for i, val := range opts.Conv {
+			if i == decodeAt {
+				reader = newCharsetDecoder(reader, opts.InputEncoding)
+			}
+			if i == encodeAt {
+				reader = newCharsetEncoder(reader, opts.OutputEncoding, opts.EncodingStrict)
+			}
 			switch val {
 			case "lower_case":
-				reader = &CaseReader{reader: reader, toUpper: false}
+				reader = newCaseReader(reader, lowerCase, opts.Locale)
 			case "upper_case":
-				reader = &CaseReader{reader: reader, toUpper: true}
+				reader = newCaseReader(reader, upperCase, opts.Locale)
+			case "swap_case":
+				reader = &CaseReader{reader: reader, mode: swapCase}
+			case "title_case":
+				reader = &CaseReader{reader: reader, mode: titleCase}
 			case "trim_spaces":
-				reader = &TrimReader{reader: reader}
+				reader = &TrimReader{reader: reader, trimLeft: true, trimRight: true}
+			case "trim_left":
+				reader = &TrimReader{reader: reader, trimLeft: true}
+			case "trim_right":
+				reader = &TrimReader{reader: reader, trimRight: true}
+			case "squeeze_spaces":
+				reader = &SqueezeReader{reader: reader}
+			case "nfc":
+				reader = &NormReader{reader: reader, form: norm.NFC}
+			case "nfd":
+				reader = &NormReader{reader: reader, form: norm.NFD}
+			case "ascii_fold":
+				reader = &FoldReader{reader: reader, replace: opts.ASCIIFoldReplace}
+			case "dos2unix":
+				reader = &NewlineReader{reader: reader, loneCR: '\r'}
+			case "unix2dos":
+				reader = &Unix2DosReader{reader: reader}
+			case "normalize_newlines":
+				reader = &NewlineReader{reader: reader, loneCR: '\n'}
+			case "expand_tabs":
+				reader = &ExpandTabsReader{reader: reader, tabWidth: opts.TabWidth}
+			case "unexpand":
+				reader = &UnexpandReader{reader: reader, tabWidth: opts.TabWidth}
+			case "strip_bom":
+				reader = &StripBOMReader{reader: reader}
+			case "add_bom":
+				reader = &AddBOMReader{reader: reader}
+			case "strip_control":
+				reader = &StripControlReader{reader: reader}
+			case "fix_utf8":
+				reader = &FixUTF8Reader{reader: reader}
+			case "reverse_lines":
+				reverse := &ReverseLinesReader{reader: reader, maxSpool: opts.MaxSpool}
+				if i == 0 && file != nil && !opts.Direct && !slices.Contains(opts.Conv, "bunzip2") &&
+					opts.MaxRate == 0 && opts.retry == nil && opts.noError == nil {
+					if section := seekableSection(file, opts.Offset, opts.Limit); section != nil {
+						reverse.source = &countingReaderAt{reader: section, counter: opts.source}
+						reverse.pos = section.Size()
+						diag.verbosef("reverse_lines reads the source backwards without spooling")
+					}
+				}
+				reader, opts.reverse = reverse, reverse
+			case "number_lines":
+				reader = &NumberLinesReader{reader: reader}
+			case "squeeze_blank":
+				reader = &SqueezeBlankReader{reader: reader, whitespace: opts.BlankWhitespace}
+			case "head_lines":
+				reader = &HeadLinesReader{reader: reader, remaining: opts.HeadLines}
+			case "tail_lines":
+				reader = &TailLinesReader{reader: reader, lines: make([][]byte, opts.TailLines)}
+			case "block":
+				reader = &BlockReader{reader: reader, cbs: opts.ConvBlockSize, report: humanOutput(opts)}
+			case "unblock":
+				reader = &UnblockReader{reader: reader, cbs: opts.ConvBlockSize}
+			case "wrap":
+				reader = &WrapReader{reader: reader, width: opts.WrapWidth}
+			case "map":
+				reader = &RuneMapReader{reader: reader, table: opts.RuneMap}
+			case "replace":
+				reader = &ReplaceReader{
+					reader:      reader,
+					pattern:     opts.ReplacePattern,
+					replacement: []byte(opts.ReplaceWith),
+					lines:       lineBuffer{maxLength: opts.MaxLineLength},
+				}
+			case "match":
+				reader = &MatchReader{
+					reader:  reader,
+					pattern: opts.MatchPattern,
+					lines:   lineBuffer{maxLength: opts.MaxLineLength},
+					report:  humanOutput(opts),
+				}
+			case "exclude":
+				reader = &MatchReader{
+					reader:  reader,
+					pattern: opts.ExcludePattern,
+					invert:  true,
+					lines:   lineBuffer{maxLength: opts.MaxLineLength},
+				}
+			case "base64_encode":
+				reader = &Base64EncodeReader{reader: reader}
+			case "base64_decode":
+				reader = &Base64DecodeReader{reader: reader}
+			case "hex_encode":
+				reader = &HexEncodeReader{reader: reader}
+			case "hex_decode":
+				reader = &HexDecodeReader{reader: reader}
+			case "url_encode":
+				reader = &URLEncodeReader{reader: reader}
+			case "url_decode":
+				reader = &URLDecodeReader{reader: reader}
+			case "json_escape":
+				reader = &JSONEscapeReader{reader: reader, asciiOnly: opts.JSONEscapeASCII}
+			case "rot13":
+				reader = &ByteMapReader{reader: reader, table: rot13Table}
+			case "shift":
+				reader = &ByteMapReader{reader: reader, table: shiftTable(opts.Shift, opts.ShiftBytes)}
+			case "ebcdic":
+				reader = &ByteMapReader{reader: reader, table: asciiToEBCDIC}
+			case "ibm":
+				reader = &ByteMapReader{reader: reader, table: asciiToIBM}
+			case "ascii":
+				reader = &ByteMapReader{reader: reader, table: ebcdicToASCII}
+			case "swab":
+				reader = &SwabReader{reader: reader}
+			case "xor":
+				reader = &XorReader{reader: reader, key: opts.XorKey}
+			case "encrypt":
+				reader = &EncryptReader{reader: reader, key: opts.Key}
+			case "decrypt":
+				reader = &DecryptReader{reader: reader, key: opts.Key}
+			case "gzip":
+				reader = newGzipReader(reader, opts.GzipLevel)
+			case "gunzip":
+				reader = &GunzipReader{reader: reader}
+			case "zstd_encode":
+				reader = newZstdReader(reader, opts.ZstdLevel)
+			case "zstd_decode":
+				reader = &ZstdDecodeReader{reader: reader}
+			case "bunzip2":
+				continue
 			}
+			diag.verbosef("added conv %s", val)
 		}
 	}
+	if decodeAt == len(opts.Conv) {
+		reader = newCharsetDecoder(reader, opts.InputEncoding)
+	}
+	if encodeAt == len(opts.Conv) {
+		reader = newCharsetEncoder(reader, opts.OutputEncoding, opts.EncodingStrict)
+	}
 
 	return reader, nil
 }
 
-func createWriter(to string) (io.Writer, error) {
-	if to == "" {
-		return os.Stdout, nil
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+func directFlag(opts *Options) int {
+	if opts.Direct {
+		return oDirect
 	}
+	return 0
+}
 
-	_, err := os.Stat(to)
-	if !os.IsNotExist(err) {
+func openAtOffset(to string, offset uint64, perm os.FileMode) (*os.File, error) {
+	file, err := os.OpenFile(to, os.O_CREATE|os.O_WRONLY, perm)
+	if err != nil {
 		return nil, err
 	}
 
-	return os.Create(to)
+	_, err = file.Seek(int64(offset), io.SeekStart)
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("%w: -seek requires a seekable -to: %w", ErrInvalidFlag, err)
+	}
+	return file, nil
 }
 
-func main() {
-	opts, err := ParseFlags()
+// truncateAt cuts a regular file at the offset, like dd does for seek
+// without conv=notrunc. Devices keep their size.
+func truncateAt(file *os.File, offset uint64) error {
+	stat, err := file.Stat()
+	if err == nil && stat.Mode().IsRegular() {
+		err = file.Truncate(int64(offset))
+	}
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	return nil
+}
+
+func skipOutputBlocks(opts *Options) (io.WriteCloser, error) {
+	offset := opts.SeekBlocks * opts.WriteBlockSize
+	file, writer := os.Stdout, io.WriteCloser(nopWriteCloser{os.Stdout})
+	if opts.To != "" {
+		var err error
+		file, err = openWithMode(opts, os.O_CREATE|os.O_WRONLY)
+		if err == nil && !opts.NoTrunc {
+			err = truncateAt(file, offset)
+		}
+		if err != nil {
+			return nil, err
+		}
+		writer = file
+	}
+
+	if _, err := file.Seek(int64(offset), io.SeekStart); err == nil {
+		diag.verbosef("skipped %d bytes of output via seek", offset)
+		return writer, nil
+	}
+	diag.verbosef("output is not seekable, writing %d zero bytes instead of seeking", offset)
+
+	zeros := make([]byte, opts.WriteBlockSize)
+	for range opts.SeekBlocks {
+		if _, err := writer.Write(zeros); err != nil {
+			_ = writer.Close()
+			return nil, err
+		}
+	}
+	return writer, nil
+}
+
+func checkOverwrite(opts *Options) (exists bool, err error) {
+	stat, err := os.Stat(opts.To)
+	switch {
+	case os.IsNotExist(err):
+		return false, nil
+	case err != nil:
+		return false, err
+	case stat.IsDir():
+		return false, fmt.Errorf("destination %s is a directory", opts.To)
+	case !opts.Force && opts.Interactive:
+		if err = confirmOverwrite(opts.To); err != nil {
+			return false, err
+		}
+	case !opts.Force && opts.Backup == "":
+		target, evalErr := filepath.EvalSymlinks(opts.To)
+		if evalErr != nil {
+			target = opts.To
+		}
+		return false, fmt.Errorf("%w: %s, use -force to overwrite it", ErrDestinationExists, target)
+	}
+	return true, nil
+}
+
+func createWriter(opts *Options) (io.WriteCloser, error) {
+	if opts.To != "" {
+		if err := prepareParent(opts); err != nil {
+			return nil, err
+		}
+	}
+	if opts.SeekBlocks != 0 {
+		return skipOutputBlocks(opts)
+	}
+	if opts.To == "" {
+		diag.verbosef("writing to stdout")
+		return nopWriteCloser{os.Stdout}, nil
+	}
+	if opts.Append {
+		diag.verbosef("appending to destination %s", opts.To)
+		return openWithMode(opts, os.O_APPEND|os.O_CREATE|os.O_WRONLY)
+	}
+	if opts.Seek != 0 {
+		diag.verbosef("writing to destination %s at offset %d", opts.To, opts.Seek)
+		file, err := openAtOffset(opts.To, opts.Seek, createPerm(opts))
+		if err == nil && !opts.NoTrunc {
+			err = truncateAt(file, opts.Seek)
+		}
+		if err == nil {
+			err = applyMode(opts, file)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return file, nil
+	}
+
+	exists, err := checkOverwrite(opts)
+	if err != nil {
+		return nil, err
+	}
+	mode := createMode()
+	if stat, statErr := os.Stat(opts.To); exists && statErr == nil {
+		mode = stat.Mode().Perm()
+	}
+	if opts.Mode != nil {
+		mode = *opts.Mode
+	}
+	if exists && opts.Backup != "" {
+		if err = backupDestination(opts); err != nil {
+			return nil, err
+		}
+	} else if exists {
+		diag.verbosef("overwriting existing destination %s", opts.To)
+	}
+
+	if opts.Atomic {
+		return createAtomic(opts.To, mode, opts.Fsync)
+	}
+
+	flags := os.O_RDWR | os.O_CREATE | directFlag(opts)
+	if !opts.NoTrunc {
+		flags |= os.O_TRUNC
+	}
+	file, err := openWithMode(opts, flags)
+	if err != nil {
+		return nil, err
+	}
+	diag.verbosef("created destination %s", opts.To)
+	if opts.Direct {
+		return newDirectWriter(file, opts.WriteBlockSize), nil
+	}
+	return file, nil
+}
+
+func copyJobs(opts *Options) ([]*Options, error) {
+	if opts.Symlinks == "skip" && len(opts.From) != 0 {
+		opts.From = withoutSymlinks(opts.From)
+		if len(opts.From) == 0 {
+			return nil, nil
+		}
+	}
+
+	if stat, err := os.Stat(opts.To); opts.To == "" || len(opts.Tee) != 0 || err != nil || !stat.IsDir() {
+		for _, from := range opts.From {
+			switch {
+			case opts.Symlinks != "copy" || !isSymlink(from):
+			case opts.To == "":
+				return nil, fmt.Errorf("-symlinks copy requires -to to recreate symlink %s", from)
+			case len(opts.From) > 1:
+				return nil, fmt.Errorf("-symlinks copy can not concatenate symlink %s with other -from", from)
+			}
+		}
+		return []*Options{opts}, nil
+	}
+	if len(opts.From) == 0 {
+		return nil, fmt.Errorf("destination %s is a directory, give a file name in -to when reading stdin", opts.To)
+	}
+	if isInfiniteSource(opts.From[0]) {
+		return nil, fmt.Errorf("destination %s is a directory, give a file name in -to when reading %s", opts.To, opts.From[0])
+	}
+
+	jobs := make([]*Options, 0, len(opts.From))
+	for _, from := range opts.From {
+		job := *opts
+		job.From = []string{from}
+		job.To = filepath.Join(opts.To, filepath.Base(from))
+		jobs = append(jobs, &job)
+	}
+	return jobs, nil
+}
+
+func runCopy(opts *Options) (*blockCopier, error) {
+	states := snapshotDestinations(opts)
+	copier, err := copyToDestination(opts)
 	if err != nil {
-		_, _ = fmt.Fprintln(os.Stderr, "can not parse flags:", err)
-		os.Exit(1)
+		err = handlePartialOutput(opts, states, err)
+	}
+	return copier, err
+}
+
+func copyToDestination(opts *Options) (*blockCopier, error) {
+	if err := checkSameFile(opts); err != nil {
+		return nil, destinationError(err)
+	}
+	if opts.SkipIdentical != "" {
+		identical, err := identicalDestination(opts)
+		if err != nil {
+			return nil, destinationError(fmt.Errorf("can not compare with destination: %w", err))
+		}
+		if identical {
+			return nil, nil
+		}
+	}
+	if !opts.deadline.IsZero() && !time.Now().Before(opts.deadline) {
+		return nil, fmt.Errorf("%w after %s", ErrTimeout, opts.Timeout)
+	}
+	if isInterrupted() {
+		return nil, ErrInterrupted
+	}
+	if opts.Resume {
+		if err := prepareResume(opts); err != nil {
+			return nil, destinationError(fmt.Errorf("can not resume: %w", err))
+		}
 	}
 
 	reader, err := CreateReader(opts)
+	if opts.input != nil {
+		defer opts.input.Close()
+	}
+	if opts.reverse != nil {
+		defer opts.reverse.Close()
+	}
+	if err != nil {
+		return nil, sourceError(fmt.Errorf("can not create reader: %w", err))
+	}
+
+	start := verifyStart(opts)
+	var writer io.WriteCloser
+	if len(opts.Tee) != 0 {
+		writer, err = createTeeWriter(opts)
+	} else {
+		writer, err = createWriter(opts)
+	}
+	if err != nil {
+		return nil, destinationError(fmt.Errorf("can not create writer: %w", err))
+	}
+	if opts.Sparse {
+		writer = newSparseWriter(writer)
+	}
+
+	copier := &blockCopier{
+		reader:      reader,
+		writer:      writer,
+		source:      opts.source,
+		blockSize:   opts.ReadBlockSize,
+		count:       opts.Count,
+		sync:        slices.Contains(opts.Conv, "sync"),
+		direct:      opts.Direct,
+		digests:     newDigests(opts.Hash),
+		maxRate:     opts.MaxRate,
+		retry:       opts.retry,
+		noError:     opts.noError,
+		errorMap:    opts.ErrorMap,
+		fullBlock:   opts.FullBlock,
+		started:     time.Now(),
+		outputLimit: int64(opts.LimitOutput),
+		runeOutput:  runeOutput(opts),
+	}
+	if opts.Verify {
+		copier.verify = sha256.New()
+	}
+	var reporter *progressReporter
+	if opts.Progress {
+		reporter = startProgress(opts.source, humanOutput(opts))
+	}
+	stopStatus := watchStatusSignals(copier, humanOutput(opts))
+	if opts.WriteBlockSize != opts.ReadBlockSize {
+		copier.pending = make([]byte, 0, opts.WriteBlockSize)
+		diag.verbosef("reading in blocks of %d bytes, writing in blocks of %d bytes", opts.ReadBlockSize, opts.WriteBlockSize)
+	} else {
+		diag.verbosef("copying in blocks of %d bytes", opts.BlockSize)
+	}
+	finished, err := copier.copyUntil(opts.deadline, interruptibleFiles(opts))
+	stopStatus()
+	reporter.stop()
+	if err != nil {
+		abortWriter(writer)
+	}
+	if opts.ErrorMap != "" && finished {
+		if mapErr := writeErrorMap(opts.ErrorMap, opts.noError.bad, int(opts.ReadBlockSize)); mapErr != nil {
+			diag.errorf("can not write -error-map: %v", mapErr)
+		}
+	}
+	if errors.Is(err, ErrTimeout) {
+		err = fmt.Errorf("%w after %s", err, opts.Timeout)
+		if !finished {
+			return nil, err
+		}
+		return copier, err
+	}
+	if errors.Is(err, ErrInterrupted) && !finished {
+		return nil, fmt.Errorf("%w after %d bytes written", err, copier.written.Load())
+	}
+	if errors.Is(err, ErrDecompression) || errors.Is(err, ErrInterrupted) {
+		return copier, err
+	}
+	var openErr *openSourceError
+	if errors.As(err, &openErr) {
+		return copier, sourceError(fmt.Errorf("%w after %d bytes written", openErr, copier.written.Load()))
+	}
+	if exitCode(err) == exitDestination {
+		return copier, writeFailure(opts, copier.written.Load(), err)
+	}
+	if err != nil {
+		return copier, fmt.Errorf("error while copping: %w", err)
+	}
+
+	if opts.Fsync {
+		if err = syncWriter(writer); err != nil {
+			abortWriter(writer)
+			return copier, destinationError(fmt.Errorf("can not sync writer: %w", err))
+		}
+	}
+
+	err = writer.Close()
 	if err != nil {
-		_, _ = fmt.Fprintln(os.Stderr, "can not create reader:", err)
-		os.Exit(1)
+		return copier, destinationError(fmt.Errorf("can not close writer: %w", err))
+	}
+
+	if opts.Verify {
+		if err = verifyDestination(opts, copier, start); err != nil {
+			return copier, err
+		}
+	}
+
+	if len(opts.Preserve) != 0 {
+		if err = preserveAttributesOf(opts); err != nil {
+			return copier, destinationError(fmt.Errorf("can not preserve attributes: %w", err))
+		}
 	}
 
-	writer, err := createWriter(opts.To)
+	if len(copier.digests) != 0 {
+		output := humanOutput(opts)
+		if opts.HashToStdout {
+			output = os.Stdout
+		}
+		for _, destination := range append([]string{opts.To}, opts.Tee...) {
+			if destination == "" {
+				destination = "-"
+			}
+			copier.digests.print(output, destination)
+		}
+	}
+
+	if copier.sync && !opts.Quiet {
+		_, _ = fmt.Fprintf(humanOutput(opts), "%d bytes written\n", copier.written.Load())
+	}
+	if isFlagSet("count") && !opts.Quiet {
+		_, _ = fmt.Fprintf(humanOutput(opts), "%s records in\n%s records out\n", copier.recordsIn, copier.recordsOut)
+	}
+	return copier, nil
+}
+
+func main() {
+	opts, err := ParseFlags()
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		_, _ = fmt.Fprintln(os.Stderr, "can not create writer:", err)
-		os.Exit(1)
+		diag.errorf("can not parse flags: %v", err)
+		os.Exit(exitInvalid)
+	}
+	if opts.Version || opts.VersionJSON {
+		if err = printVersion(os.Stdout, opts.VersionJSON); err != nil {
+			diag.errorf("can not print version: %v", err)
+			os.Exit(exitInvalid)
+		}
+		return
+	}
+	diag = newLogger(os.Stderr, opts)
+	if opts.LogFile != "" {
+		if file := diag.openFile(opts.LogFile); file != nil {
+			defer file.Close()
+		}
+	}
+	diag.event(slog.LevelInfo, "started", "args", strings.Join(os.Args[1:], " "))
+	handleInterrupts()
+	if opts.JSON {
+		opts.results = os.Stderr
+	}
+	if opts.JSONFile != "" {
+		results, err := os.Create(opts.JSONFile)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "can not create -json-file: %v\n", err)
+			os.Exit(exitInvalid)
+		}
+		defer results.Close()
+		opts.results = results
+	}
+	if opts.Timeout != 0 {
+		opts.deadline = time.Now().Add(opts.Timeout)
 	}
 
-	_, err = io.CopyBuffer(struct{ io.Writer }{writer}, reader, make([]byte, opts.BlockSize))
+	if opts.Recursive {
+		stats, err := copyTree(opts)
+		if errors.Is(err, ErrInvalidFlag) {
+			diag.errorf("can not parse flags: %v", err)
+			os.Exit(exitInvalid)
+		}
+		diag.infof("%s", stats)
+		if err != nil {
+			os.Exit(exitCode(err))
+		}
+		if stats.failed != 0 {
+			os.Exit(exitCode(stats.firstErr))
+		}
+		return
+	}
+
+	jobs, err := copyJobs(opts)
 	if err != nil {
-		_, _ = fmt.Fprintln(os.Stderr, "error while copping:", err)
-		os.Exit(1)
+		diag.errorf("can not create writer: %v", err)
+		reportResult(opts, nil, time.Now(), err)
+		os.Exit(exitInvalid)
+	}
+
+	for _, job := range jobs {
+		if job.Symlinks == "copy" && len(job.From) == 1 && isSymlink(job.From[0]) {
+			if opts.DryRun {
+				_, _ = fmt.Fprintf(os.Stdout, "symlink: %s, will be recreated as %s\n", job.From[0], job.To)
+				continue
+			}
+			if err = copySymlink(job.From[0], job.To, job.Force); err != nil {
+				diag.errorf("can not create writer: %v", err)
+				os.Exit(exitDestination)
+			}
+			continue
+		}
+		if opts.DryRun {
+			if err = dryRun(job, os.Stdout); err != nil {
+				diag.errorf("dry run failed: %v", err)
+				os.Exit(exitInvalid)
+			}
+			continue
+		}
+
+		started := time.Now()
+		copier, err := runCopy(job)
+		reportResult(job, copier, started, err)
+		if err != nil {
+			diag.errorf("%v", err)
+			if copier != nil {
+				diag.infof("%s", copier.summary(true))
+			}
+			os.Exit(exitCode(err))
+		}
+		if copier == nil {
+			diag.infof("%s is identical to %s, nothing copied", job.To, job.From[0])
+			continue
+		}
+		diag.infof("%s", copier.summary(false))
 	}
 }
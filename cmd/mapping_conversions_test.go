@@ -0,0 +1,245 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func allBytes() string {
+	data := make([]byte, 256)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	return string(data)
+}
+
+func TestMappingConversions(t *testing.T) {
+	binPath := composeBinaryPath()
+	cmd := exec.Command("go", "build", "-o", binPath, "./")
+	assert.NoError(t, cmd.Run())
+	defer func() {
+		assert.NoError(t, os.Remove(binPath))
+	}()
+
+	t.Run("ok, rot13", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "rot13")
+		cmd.Stdin = strings.NewReader("Hello, World! Привет")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, "Uryyb, Jbeyq! Привет", stdout.String())
+	})
+
+	t.Run("ok, rot13 applied twice restores any input", func(t *testing.T) {
+		for _, input := range []string{testInput, allBytes()} {
+			cmd = exec.Command(binPath, "-quiet", "-conv", "rot13,rot13", "-block-size", "7")
+			cmd.Stdin = strings.NewReader(input)
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.NoError(t, err)
+			assert.Zero(t, stderr.Len(), stderr.String())
+			assert.Equal(t, input, stdout.String())
+		}
+	})
+
+	t.Run("ok, ebcdic", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "ebcdic")
+		cmd.Stdin = strings.NewReader("Hello 42")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, "\xc8\x85\x93\x93\x96\x40\xf4\xf2", stdout.String())
+	})
+
+	t.Run("ok, ebcdic and then ascii restore the whole byte range", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "ebcdic", "-block-size", "3")
+		cmd.Stdin = strings.NewReader(allBytes())
+		encoded := &strings.Builder{}
+		cmd.Stdout = encoded
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.NoError(t, cmd.Run())
+		assert.Zero(t, stderr.Len(), stderr.String())
+
+		cmd = exec.Command(binPath, "-quiet", "-conv", "ascii", "-block-size", "3")
+		cmd.Stdin = strings.NewReader(encoded.String())
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, allBytes(), stdout.String())
+	})
+
+	t.Run("error, ibm together with upper_case", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "ibm,upper_case")
+		cmd.Stdin = strings.NewReader(testInput)
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.NotZero(t, stderr.Len())
+		assert.Zero(t, stdout.Len())
+	})
+
+	t.Run("ok, swab with odd block-size and odd input length", func(t *testing.T) {
+		for _, blockSize := range []string{"1", "3", "1024"} {
+			cmd = exec.Command(binPath, "-quiet", "-conv", "swab", "-block-size", blockSize)
+			cmd.Stdin = strings.NewReader("\x00\x01\x02\x03\xfe\xffz")
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.NoError(t, err)
+			assert.Zero(t, stderr.Len(), stderr.String())
+			assert.Equal(t, "\x01\x00\x03\x02\xff\xfez", stdout.String())
+		}
+	})
+
+	t.Run("ok, xor keeps key phase across blocks", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "xor", "-xor-key", "0102ff", "-block-size", "2")
+		cmd.Stdin = strings.NewReader("\x00\x00\x00\x01\x01\x01\xff")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, "\x01\x02\xff\x00\x03\xfe\xfe", stdout.String())
+	})
+
+	t.Run("ok, xor applied twice restores the input", func(t *testing.T) {
+		input := testInput + allBytes()
+		cmd = exec.Command(binPath, "-quiet", "-conv", "xor", "-xor-key", "deadbeef", "-block-size", "7")
+		cmd.Stdin = strings.NewReader(input)
+		encrypted := &strings.Builder{}
+		cmd.Stdout = encrypted
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.NoError(t, cmd.Run())
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.NotEqual(t, input, encrypted.String())
+
+		cmd = exec.Command(binPath, "-quiet", "-conv", "xor", "-xor-key", "deadbeef", "-block-size", "5")
+		cmd.Stdin = strings.NewReader(encrypted.String())
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, input, stdout.String())
+	})
+
+	t.Run("error with empty or invalid xor-key", func(t *testing.T) {
+		for _, key := range []string{"", "abc", "zz"} {
+			cmd = exec.Command(binPath, "-quiet", "-conv", "xor", "-xor-key", key)
+			cmd.Stdin = strings.NewReader(testInput)
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.Error(t, err)
+			assert.NotZero(t, stderr.Len())
+			assert.Zero(t, stdout.Len())
+		}
+	})
+
+	t.Run("ok, shift round-trips", func(t *testing.T) {
+		for _, tc := range []struct {
+			shift string
+			back  string
+			extra []string
+			input string
+		}{
+			{"5", "-5", nil, "Hello, Zz! Привет"},
+			{"-31", "31", nil, "Hello, Zz! Привет"},
+			{"300", "-300", []string{"-shift-bytes"}, allBytes()},
+		} {
+			cmd = exec.Command(binPath, append([]string{"-quiet", "-conv", "shift", "-shift", tc.shift}, tc.extra...)...)
+			cmd.Stdin = strings.NewReader(tc.input)
+			shifted := &strings.Builder{}
+			cmd.Stdout = shifted
+			assert.NoError(t, cmd.Run())
+
+			cmd = exec.Command(binPath, append([]string{"-quiet", "-conv", "shift", "-shift", tc.back}, tc.extra...)...)
+			cmd.Stdin = strings.NewReader(shifted.String())
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.NoError(t, err)
+			assert.Zero(t, stderr.Len(), stderr.String())
+			assert.NotEqual(t, tc.input, shifted.String())
+			assert.Equal(t, tc.input, stdout.String(), tc.shift)
+		}
+	})
+
+	t.Run("ok, shift keeps non-letters", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "shift", "-shift", "-1")
+		cmd.Stdin = strings.NewReader("abc XYZ 123 ж")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "zab WXY 123 ж", stdout.String())
+	})
+
+	t.Run("fail, shift without -shift", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "shift")
+		cmd.Stdin = strings.NewReader("abc")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "shift requires -shift")
+	})
+}
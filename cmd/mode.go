@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+func parseMode(name, value string) (*os.FileMode, error) {
+	if value == "" {
+		return nil, nil
+	}
+	bits, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || bits > uint64(os.ModePerm) {
+		return nil, fmt.Errorf("%w: -%s must be octal permission bits from 0000 to 0777, got %q", ErrInvalidFlag, name, value)
+	}
+	mode := os.FileMode(bits)
+	return &mode, nil
+}
+
+func createPerm(opts *Options) os.FileMode {
+	if opts.Mode != nil {
+		return *opts.Mode
+	}
+	return 0o666
+}
+
+func openWithMode(opts *Options, flag int) (*os.File, error) {
+	file, err := os.OpenFile(opts.To, flag, createPerm(opts))
+	if err != nil {
+		return nil, err
+	}
+	if err = applyMode(opts, file); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func applyMode(opts *Options, file *os.File) error {
+	if opts.Mode == nil {
+		return nil
+	}
+	if err := file.Chmod(*opts.Mode); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("can not set -mode of %s: %w", file.Name(), err)
+	}
+	diag.verbosef("set mode %v of %s", *opts.Mode, file.Name())
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"syscall"
+)
+
+type NoErrorReader struct {
+	reader    io.Reader
+	blockSize int
+	sync      bool
+	offset    int64
+	seekable  bool
+	started   bool
+	bad       []int64
+}
+
+func isSkippable(err error) bool {
+	return !errors.Is(err, io.EOF) &&
+		!errors.Is(err, fs.ErrPermission) &&
+		!errors.Is(err, fs.ErrClosed) &&
+		!errors.Is(err, syscall.EISDIR)
+}
+
+func (nr *NoErrorReader) Read(p []byte) (n int, err error) {
+	if !nr.started {
+		nr.started = true
+		if seeker, ok := nr.reader.(io.Seeker); ok {
+			if position, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+				nr.offset, nr.seekable = position, true
+			}
+		}
+	}
+
+	for {
+		n, err = nr.reader.Read(p)
+		nr.offset += int64(n)
+		if err == nil || !isSkippable(err) {
+			return n, err
+		}
+		if n != 0 {
+			return n, nil
+		}
+
+		diag.errorf("read error at byte %d: %v", nr.offset, err)
+		nr.bad = append(nr.bad, nr.offset)
+		nr.offset += int64(nr.blockSize)
+		if nr.seekable {
+			if _, err = nr.reader.(io.Seeker).Seek(nr.offset, io.SeekStart); err != nil {
+				return 0, err
+			}
+		}
+		if nr.sync {
+			n = min(len(p), nr.blockSize)
+			clear(p[:n])
+			return n, nil
+		}
+	}
+}
+
+func (nr *NoErrorReader) Seek(offset int64, whence int) (int64, error) {
+	seeker, ok := nr.reader.(io.Seeker)
+	if !ok {
+		return 0, fmt.Errorf("source is not seekable")
+	}
+	position, err := seeker.Seek(offset, whence)
+	if err == nil {
+		nr.offset, nr.seekable, nr.started = position, true, true
+	}
+	return position, err
+}
+
+func (nr *NoErrorReader) summary(errorMap string) string {
+	if len(nr.bad) == 0 {
+		return ""
+	}
+	if errorMap != "" {
+		return fmt.Sprintf(", %d unreadable blocks listed in %s", len(nr.bad), errorMap)
+	}
+	offsets := make([]string, len(nr.bad))
+	for i, offset := range nr.bad {
+		offsets[i] = fmt.Sprint(offset)
+	}
+	return fmt.Sprintf(", %d unreadable blocks at %s", len(nr.bad), strings.Join(offsets, ", "))
+}
+
+func writeErrorMap(path string, bad []int64, blockSize int) error {
+	var builder strings.Builder
+	for _, offset := range bad {
+		_, _ = fmt.Fprintf(&builder, "%d %d\n", offset, blockSize)
+	}
+	return os.WriteFile(path, []byte(builder.String()), 0o644)
+}
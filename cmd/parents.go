@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func checkParent(opts *Options) (missing bool, err error) {
+	parent := filepath.Dir(opts.To)
+	stat, err := os.Stat(parent)
+	if err == nil && stat.IsDir() {
+		return false, nil
+	}
+	if file := fileAncestor(opts.To); file != "" {
+		return false, fmt.Errorf("can not create %s: %s is not a directory", opts.To, file)
+	}
+	switch {
+	case !os.IsNotExist(err):
+		return false, err
+	case !opts.Parents:
+		return false, fmt.Errorf("can not create %s: parent directory %s does not exist, create it or pass -parents", opts.To, parent)
+	}
+	return true, nil
+}
+
+func fileAncestor(path string) string {
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if stat, err := os.Stat(dir); err == nil {
+			if stat.IsDir() {
+				return ""
+			}
+			return dir
+		}
+		if dir == filepath.Dir(dir) {
+			return ""
+		}
+	}
+}
+
+func prepareParent(opts *Options) error {
+	missing, err := checkParent(opts)
+	if err != nil || !missing {
+		return err
+	}
+	parent := filepath.Dir(opts.To)
+	if err = os.MkdirAll(parent, opts.DirMode); err != nil {
+		return fmt.Errorf("can not create parent directories of %s: %w", opts.To, err)
+	}
+	diag.verbosef("created parent directories %s", parent)
+	return nil
+}
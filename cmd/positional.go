@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+func printUsage() {
+	name := filepath.Base(os.Args[0])
+	output := flag.CommandLine.Output()
+	_, _ = fmt.Fprintf(output, "Usage:\n"+
+		"  %[1]s [flags] [SRC [DST]]\n"+
+		"  %[1]s [flags] SRC... DIR\n"+
+		"  %[1]s [flags] [-from SRC] [-to DST]\n"+
+		"  %[1]s [flags] [if=SRC] [of=DST] [bs=N] [count=N] [skip=N] [seek=N] [conv=LIST] [iflag=LIST]\n"+
+		"SRC and DST may be - for stdin and stdout, which are also used when they are omitted.\n"+
+		"A file named - is given as ./-.\n\n"+
+		"Flags:\n", name)
+	flag.PrintDefaults()
+}
+
+func applyPositional(args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	explicit := setFlags()
+
+	sources, destination := args, ""
+	if len(args) > 1 {
+		sources, destination = args[:len(args)-1], args[len(args)-1]
+	}
+	if len(sources) > 1 {
+		if stat, err := os.Stat(destination); err != nil || !stat.IsDir() {
+			return fmt.Errorf("%w: with more than two arguments the last one must be a directory, got %s", ErrInvalidFlag, destination)
+		}
+		if slices.Contains(sources, "-") {
+			return fmt.Errorf("%w: - (stdin) must be the only source", ErrInvalidFlag)
+		}
+	}
+	if explicit["from"] {
+		return fmt.Errorf("%w: source given both as -from and as argument %s", ErrInvalidFlag, sources[0])
+	}
+	if destination != "" && explicit["to"] {
+		return fmt.Errorf("%w: destination given both as -to and as argument %s", ErrInvalidFlag, destination)
+	}
+
+	for _, source := range sources {
+		if err := flag.Set("from", source); err != nil {
+			return err
+		}
+	}
+	if destination != "" {
+		return flag.Set("to", destination)
+	}
+	return nil
+}
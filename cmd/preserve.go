@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+var preserveAttributes = []string{"mode", "times"}
+
+func parsePreserve(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	attributes := strings.Split(value, ",")
+	for _, attribute := range attributes {
+		if !slices.Contains(preserveAttributes, attribute) {
+			return nil, fmt.Errorf("%w: unknown -preserve %q, supported: %s", ErrInvalidFlag, attribute, strings.Join(preserveAttributes, ", "))
+		}
+	}
+	return attributes, nil
+}
+
+func preserveAttributesOf(opts *Options) error {
+	if len(opts.From) != 1 || opts.To == "" {
+		diag.infof("warning: -preserve needs a single -from file and a -to file, ignored")
+		return nil
+	}
+
+	source, err := os.Stat(opts.From[0])
+	if err != nil {
+		return err
+	}
+	destination, err := os.Stat(opts.To)
+	if err != nil {
+		return err
+	}
+	if !source.Mode().IsRegular() || !destination.Mode().IsRegular() {
+		diag.infof("warning: -preserve needs regular files in -from and -to, ignored")
+		return nil
+	}
+
+	if slices.Contains(opts.Preserve, "mode") {
+		if err = os.Chmod(opts.To, source.Mode().Perm()); err != nil {
+			return err
+		}
+		diag.verbosef("preserved mode %v of %s", source.Mode().Perm(), opts.To)
+	}
+	if slices.Contains(opts.Preserve, "times") {
+		if err = os.Chtimes(opts.To, accessTime(source), source.ModTime()); err != nil {
+			return err
+		}
+		diag.verbosef("preserved times of %s", opts.To)
+	}
+	return nil
+}
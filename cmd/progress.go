@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+type progressReporter struct {
+	source    *countingReader
+	output    io.Writer
+	started   time.Time
+	lastRead  int64
+	lastTime  time.Time
+	lineWidth int
+	stopped   chan struct{}
+	done      chan struct{}
+}
+
+func startProgress(source *countingReader, output io.Writer) *progressReporter {
+	now := time.Now()
+	pr := &progressReporter{
+		source:   source,
+		output:   output,
+		started:  now,
+		lastTime: now,
+		stopped:  make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go pr.run()
+	return pr
+}
+
+func (pr *progressReporter) run() {
+	defer close(pr.done)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			pr.print(time.Now())
+		case <-pr.stopped:
+			pr.print(time.Now())
+			_, _ = fmt.Fprintln(pr.output)
+			return
+		}
+	}
+}
+
+func (pr *progressReporter) print(now time.Time) {
+	read := pr.source.read.Load()
+	elapsed := now.Sub(pr.started)
+
+	line := formatSize(read) + " copied"
+	if pr.source.total > 0 {
+		line += fmt.Sprintf(" (%.1f%%)", float64(read)*100/float64(pr.source.total))
+	}
+	if interval := now.Sub(pr.lastTime).Seconds(); interval > 0 {
+		line += fmt.Sprintf(", %s/s", formatSize(int64(float64(read-pr.lastRead)/interval)))
+	}
+	if elapsed > 0 {
+		line += fmt.Sprintf(", avg %s/s", formatSize(int64(float64(read)/elapsed.Seconds())))
+	}
+	line += ", " + elapsed.Round(time.Second).String()
+
+	_, _ = fmt.Fprintf(pr.output, "\r%-*s", pr.lineWidth, line)
+	pr.lineWidth = max(pr.lineWidth, len(line))
+	pr.lastRead, pr.lastTime = read, now
+}
+
+func (pr *progressReporter) stop() {
+	if pr == nil {
+		return
+	}
+	close(pr.stopped)
+	<-pr.done
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func confirmOverwrite(path string) error {
+	if controllingTerminal == "" {
+		return fmt.Errorf("%w: %s, can not ask to overwrite it without a terminal, use -force", ErrDestinationExists, path)
+	}
+	terminal, err := os.OpenFile(controllingTerminal, os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("%w: %s, can not ask to overwrite it without a terminal, use -force: %w", ErrDestinationExists, path, err)
+	}
+	defer terminal.Close()
+
+	_, _ = fmt.Fprintf(os.Stderr, "overwrite '%s'? [y/N] ", path)
+	answer, _ := bufio.NewReader(terminal).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return fmt.Errorf("%w: %s, not overwritten", ErrDestinationExists, path)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"io"
+	"time"
+)
+
+type RateLimitReader struct {
+	reader io.Reader
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimitReader(reader io.Reader, rate, burst uint64) *RateLimitReader {
+	return &RateLimitReader{
+		reader: reader,
+		rate:   float64(rate),
+		burst:  float64(max(burst, 1)),
+		tokens: float64(max(burst, 1)),
+		last:   time.Now(),
+	}
+}
+
+func (rl *RateLimitReader) Read(p []byte) (n int, err error) {
+	n, err = rl.reader.Read(p)
+	if n == 0 {
+		return n, err
+	}
+
+	now := time.Now()
+	rl.tokens = min(rl.burst, rl.tokens+now.Sub(rl.last).Seconds()*rl.rate)
+	rl.last = now
+	rl.tokens -= float64(n)
+	if rl.tokens < 0 {
+		time.Sleep(time.Duration(-rl.tokens / rl.rate * float64(time.Second)))
+	}
+	return n, err
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type treeStats struct {
+	copied   int
+	skipped  int
+	failed   int
+	firstErr error
+}
+
+func (ts treeStats) String() string {
+	return fmt.Sprintf("%d files copied, %d skipped, %d failed", ts.copied, ts.skipped, ts.failed)
+}
+
+func copyTree(opts *Options) (treeStats, error) {
+	var stats treeStats
+	root := opts.From[0]
+	if isInside(opts.To, root) {
+		return stats, fmt.Errorf("%w: -to %s is inside -from %s", ErrInvalidFlag, opts.To, root)
+	}
+
+	fail := func(path string, err error) error {
+		diag.errorf("%s: %v", path, err)
+		stats.failed++
+		if stats.firstErr == nil {
+			stats.firstErr = err
+		}
+		if opts.FailFast || errors.Is(err, ErrTimeout) || errors.Is(err, ErrInterrupted) {
+			return err
+		}
+		return nil
+	}
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return fail(path, sourceError(err))
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return fail(path, err)
+		}
+		target := filepath.Join(opts.To, rel)
+
+		if entry.Type()&fs.ModeSymlink != 0 {
+			switch opts.Symlinks {
+			case "copy":
+				if err = copySymlink(path, target, opts.Force); err != nil {
+					return fail(path, destinationError(err))
+				}
+				stats.copied++
+				return nil
+			case "follow":
+				stat, err := os.Stat(path)
+				if err != nil {
+					return fail(path, sourceError(err))
+				}
+				if stat.IsDir() {
+					diag.infof("warning: skipping symlink %s to a directory", path)
+					stats.skipped++
+					return nil
+				}
+				entry = fs.FileInfoToDirEntry(stat)
+			default:
+				diag.infof("warning: skipping symlink %s", path)
+				stats.skipped++
+				return nil
+			}
+		}
+
+		switch {
+		case entry.IsDir():
+			if err = os.MkdirAll(target, opts.DirMode); err != nil {
+				if err = fail(path, destinationError(err)); err != nil {
+					return err
+				}
+				return fs.SkipDir
+			}
+			diag.verbosef("created directory %s", target)
+			return nil
+		case !entry.Type().IsRegular():
+			diag.infof("warning: skipping %s, not a regular file", path)
+			stats.skipped++
+			return nil
+		}
+
+		job := *opts
+		job.From, job.To = []string{path}, target
+		started := time.Now()
+		copier, err := runCopy(&job)
+		reportResult(&job, copier, started, err)
+		if err != nil {
+			return fail(path, err)
+		}
+		if copier == nil {
+			diag.verbosef("%s: identical to %s, skipped", path, target)
+			stats.skipped++
+			return nil
+		}
+		diag.verbosef("%s: %s", path, copier.summary(false))
+		stats.copied++
+		return nil
+	})
+	return stats, err
+}
+
+func isInside(path, dir string) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
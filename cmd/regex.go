@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+type lineBuffer struct {
+	maxLength uint64
+	offset    uint64
+	line      []byte
+}
+
+func (lb *lineBuffer) emit(fn func(content, newline []byte)) error {
+	content, newline := lb.line, []byte(nil)
+	for _, terminator := range [][]byte{[]byte("\r\n"), []byte("\n")} {
+		if bytes.HasSuffix(content, terminator) {
+			content, newline = content[:len(content)-len(terminator)], content[len(content)-len(terminator):]
+			break
+		}
+	}
+	if uint64(len(content)) > lb.maxLength {
+		return lb.tooLong()
+	}
+	fn(content, newline)
+	lb.offset += uint64(len(lb.line))
+	lb.line = lb.line[:0]
+	return nil
+}
+
+func (lb *lineBuffer) tooLong() error {
+	return fmt.Errorf("%w: line at offset %d is longer than -max-line-length %d", ErrInvalidData, lb.offset, lb.maxLength)
+}
+
+func (lb *lineBuffer) feed(data []byte, atEOF bool, fn func(content, newline []byte)) error {
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		lb.line = append(lb.line, data[:i+1]...)
+		data = data[i+1:]
+		if err := lb.emit(fn); err != nil {
+			return err
+		}
+	}
+	lb.line = append(lb.line, data...)
+
+	if uint64(len(lb.line)) > lb.maxLength {
+		return lb.tooLong()
+	}
+	if atEOF && len(lb.line) != 0 {
+		return lb.emit(fn)
+	}
+	return nil
+}
+
+type ReplaceReader struct {
+	reader      io.Reader
+	pattern     *regexp.Regexp
+	replacement []byte
+	lines       lineBuffer
+	replaced    []byte
+}
+
+func (rr *ReplaceReader) Read(p []byte) (n int, err error) {
+	if len(rr.replaced) != 0 {
+		rr.replaced, n = copyFromChecked(p, rr.replaced)
+		return n, nil
+	}
+
+	buffer := make([]byte, len(p))
+	n, err = rr.reader.Read(buffer)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+
+	atEOF := errors.Is(err, io.EOF)
+	feedErr := rr.lines.feed(buffer[:n], atEOF, func(content, newline []byte) {
+		rr.replaced = append(rr.replaced, rr.pattern.ReplaceAll(content, rr.replacement)...)
+		rr.replaced = append(rr.replaced, newline...)
+	})
+	if feedErr != nil {
+		return 0, feedErr
+	}
+
+	if atEOF && len(rr.replaced) == 0 {
+		return 0, io.EOF
+	}
+	return rr.Read(p)
+}
+
+type MatchReader struct {
+	reader  io.Reader
+	pattern *regexp.Regexp
+	invert  bool
+	lines   lineBuffer
+	report  io.Writer
+	matches uint64
+	done    bool
+	matched []byte
+}
+
+func (mr *MatchReader) Read(p []byte) (n int, err error) {
+	if len(mr.matched) != 0 {
+		mr.matched, n = copyFromChecked(p, mr.matched)
+		return n, nil
+	}
+	if mr.done {
+		return 0, io.EOF
+	}
+
+	buffer := make([]byte, len(p))
+	n, err = mr.reader.Read(buffer)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+
+	atEOF := errors.Is(err, io.EOF)
+	feedErr := mr.lines.feed(buffer[:n], atEOF, func(content, newline []byte) {
+		if mr.pattern.Match(content) != mr.invert {
+			mr.matched = append(mr.matched, content...)
+			mr.matched = append(mr.matched, newline...)
+			mr.matches++
+		}
+	})
+	if feedErr != nil {
+		return 0, feedErr
+	}
+
+	if atEOF {
+		if mr.report != nil {
+			_, _ = fmt.Fprintf(mr.report, "%d lines matched\n", mr.matches)
+		}
+		mr.done = true
+	}
+	return mr.Read(p)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegexConversions(t *testing.T) {
+	binPath := composeBinaryPath()
+	cmd := exec.Command("go", "build", "-o", binPath, "./")
+	assert.NoError(t, cmd.Run())
+	defer func() {
+		assert.NoError(t, os.Remove(binPath))
+	}()
+
+	t.Run("ok, replace with lines split between blocks", func(t *testing.T) {
+		for _, blockSize := range []string{"1", "5", "1024"} {
+			cmd = exec.Command(binPath, "-quiet", "-conv", "replace", "-replace-pattern", `Bearer \S+`,
+				"-replace-with", "Bearer ***", "-block-size", blockSize)
+			cmd.Stdin = strings.NewReader("auth: Bearer abc.def\nnone\n\nBearer x Bearer yz")
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.NoError(t, err)
+			assert.Zero(t, stderr.Len(), stderr.String())
+			assert.Equal(t, "auth: Bearer ***\nnone\n\nBearer *** Bearer ***", stdout.String())
+		}
+	})
+
+	t.Run("ok, replace with group references", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "replace", "-replace-pattern", `^(\w+)=(\w+)$`, "-replace-with", "${2}=$1")
+		cmd.Stdin = strings.NewReader("key=value\nother\nа=b\n")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Zero(t, stderr.Len(), stderr.String())
+		assert.Equal(t, "value=key\nother\nа=b\n", stdout.String())
+	})
+
+	t.Run("error, replace with line longer than -max-line-length", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "replace", "-replace-pattern", "a", "-replace-with", "b",
+			"-max-line-length", "4", "-block-size", "2")
+		cmd.Stdin = strings.NewReader("aaaa\naaaaa\n")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Equal(t, "bbbb\n", stdout.String())
+		assert.Contains(t, stderr.String(), "line at offset 5 is longer than -max-line-length 4")
+	})
+
+	t.Run("fail, replace with invalid pattern", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"-conv", "replace"},
+			{"-conv", "replace", "-replace-pattern", "("},
+		} {
+			cmd = exec.Command(binPath, args...)
+			cmd.Stdin = strings.NewReader("abc")
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.Error(t, err)
+			assert.Contains(t, stderr.String(), "-replace-pattern")
+		}
+	})
+
+	t.Run("ok, match with offset, limit and upper_case", func(t *testing.T) {
+		for _, blockSize := range []string{"1", "4", "1024"} {
+			cmd = exec.Command(binPath, "-quiet", "-conv", "upper_case,match", "-match", "^[A-Z]+ ERROR",
+				"-offset", "2", "-limit", "50", "-block-size", blockSize)
+			cmd.Stdin = strings.NewReader("x\nfoo error one\nbar info\n\nbaz error two\nqux error")
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.NoError(t, err)
+			assert.Equal(t, "3 lines matched\n", stderr.String())
+			assert.Equal(t, "FOO ERROR ONE\nBAZ ERROR TWO\nQUX ERROR", stdout.String())
+		}
+	})
+
+	t.Run("ok, match without matching lines", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "match", "-match", "absent")
+		cmd.Stdin = strings.NewReader(testInput)
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "0 lines matched\n", stderr.String())
+		assert.Zero(t, stdout.Len())
+	})
+
+	t.Run("ok, exclude after match keeps CRLF", func(t *testing.T) {
+		for _, blockSize := range []string{"1", "3", "1024"} {
+			cmd = exec.Command(binPath, "-quiet", "-conv", "match,exclude", "-match", "error",
+				"-exclude-pattern", "debug$", "-block-size", blockSize)
+			cmd.Stdin = strings.NewReader("error one\r\nerror debug\r\ninfo\r\nerror two")
+			stdout := &strings.Builder{}
+			cmd.Stdout = stdout
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err := cmd.Run()
+
+			assert.NoError(t, err)
+			assert.Equal(t, "3 lines matched\n", stderr.String())
+			assert.Equal(t, "error one\r\nerror two", stdout.String())
+		}
+	})
+
+	t.Run("fail, exclude with empty pattern", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-conv", "exclude", "-exclude-pattern", "")
+		cmd.Stdin = strings.NewReader("abc")
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "exclude requires a non-empty -exclude-pattern")
+	})
+}
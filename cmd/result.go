@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+type jsonResult struct {
+	Source       string            `json:"source"`
+	Destination  string            `json:"destination"`
+	BytesRead    int64             `json:"bytes_read"`
+	BytesWritten int64             `json:"bytes_written"`
+	DurationMS   int64             `json:"duration_ms"`
+	Conversions  []string          `json:"conversions"`
+	Checksums    map[string]string `json:"checksums,omitempty"`
+	Skipped      bool              `json:"skipped,omitempty"`
+	Partial      bool              `json:"partial"`
+	Error        string            `json:"error,omitempty"`
+	ErrorClass   string            `json:"error_class,omitempty"`
+}
+
+func humanOutput(opts *Options) io.Writer {
+	if opts.JSON {
+		return io.Discard
+	}
+	return os.Stderr
+}
+
+func pathsOrDash(paths []string) string {
+	names := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if path == "" {
+			path = "-"
+		}
+		names = append(names, path)
+	}
+	if len(names) == 0 {
+		return "-"
+	}
+	return strings.Join(names, ",")
+}
+
+func reportResult(opts *Options, copier *blockCopier, started time.Time, err error) {
+	result := jsonResult{
+		Source:      pathsOrDash(opts.From),
+		Destination: pathsOrDash(append([]string{opts.To}, opts.Tee...)),
+		DurationMS:  time.Since(started).Milliseconds(),
+		Conversions: append([]string{}, opts.Conv...),
+		Skipped:     copier == nil && err == nil,
+		Partial:     err != nil,
+	}
+	if copier != nil {
+		result.BytesRead = copier.source.read.Load()
+		result.BytesWritten = copier.written.Load()
+		if len(copier.digests) != 0 && err == nil {
+			result.Checksums = make(map[string]string, len(copier.digests))
+			for _, digest := range copier.digests {
+				result.Checksums[digest.name] = hex.EncodeToString(digest.hash.Sum(nil))
+			}
+		}
+	}
+	if err != nil {
+		result.Error = err.Error()
+		result.ErrorClass = errorClass(err)
+	}
+
+	if err != nil {
+		diag.event(slog.LevelError, "failed", "from", result.Source, "to", result.Destination, "bytes_read", result.BytesRead,
+			"bytes_written", result.BytesWritten, "duration_ms", result.DurationMS, "error", result.Error, "class", result.ErrorClass)
+	} else {
+		diag.event(slog.LevelInfo, "finished", "from", result.Source, "to", result.Destination, "bytes_read", result.BytesRead,
+			"bytes_written", result.BytesWritten, "duration_ms", result.DurationMS, "skipped", result.Skipped)
+	}
+	if opts.results != nil {
+		_ = json.NewEncoder(opts.results).Encode(result)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func prepareResume(opts *Options) error {
+	destination, err := os.Stat(opts.To)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	source, err := os.Stat(opts.From[0])
+	if err != nil {
+		return err
+	}
+	if !source.Mode().IsRegular() || !destination.Mode().IsRegular() {
+		return fmt.Errorf("-resume needs regular files in -from and -to")
+	}
+
+	remaining := min(max(source.Size()-opts.Offset, 0), int64(opts.Limit))
+	if destination.Size() > remaining {
+		return fmt.Errorf("%s has %d bytes, more than %d bytes to copy from %s, can not resume",
+			opts.To, destination.Size(), remaining, opts.From[0])
+	}
+
+	opts.Offset += destination.Size()
+	opts.Limit -= uint64(destination.Size())
+	opts.Append = true
+	diag.infof("resumed at byte %d", destination.Size())
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+type RetryReader struct {
+	reader  io.Reader
+	reopen  func(offset int64) (io.Reader, error)
+	retries int
+	delay   time.Duration
+	offset  int64
+	started bool
+	count   int
+}
+
+func newRetryReader(reader io.Reader, path string, retries int, delay time.Duration) *RetryReader {
+	rr := &RetryReader{reader: reader, retries: retries, delay: delay}
+	if path != "" {
+		rr.reopen = func(offset int64) (io.Reader, error) {
+			file, err := os.Open(path)
+			if err != nil {
+				return nil, err
+			}
+			if _, err = file.Seek(offset, io.SeekStart); err != nil {
+				_ = file.Close()
+				return nil, err
+			}
+			return file, nil
+		}
+	}
+	return rr
+}
+
+func (rr *RetryReader) Read(p []byte) (n int, err error) {
+	if !rr.started {
+		rr.started = true
+		if seeker, ok := rr.reader.(io.Seeker); ok {
+			if position, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+				rr.offset = position
+			}
+		}
+	}
+
+	delay := rr.delay
+	for attempt := 1; ; attempt++ {
+		n, err = rr.reader.Read(p)
+		rr.offset += int64(n)
+		if n != 0 || !isRetryable(err) || attempt > rr.retries {
+			return n, err
+		}
+
+		diag.verbosef("read error at byte %d: %v, retry %d of %d in %s", rr.offset, err, attempt, rr.retries, delay)
+		rr.count++
+		time.Sleep(delay)
+		delay *= 2
+		if rr.reopen == nil {
+			continue
+		}
+		reader, err := rr.reopen(rr.offset)
+		if err != nil {
+			diag.verbosef("can not reopen source: %v", err)
+			continue
+		}
+		_ = rr.Close()
+		rr.reader = reader
+	}
+}
+
+func (rr *RetryReader) Seek(offset int64, whence int) (int64, error) {
+	seeker, ok := rr.reader.(io.Seeker)
+	if !ok {
+		return 0, fmt.Errorf("source is not seekable")
+	}
+	position, err := seeker.Seek(offset, whence)
+	if err == nil {
+		rr.offset, rr.started = position, true
+	}
+	return position, err
+}
+
+func (rr *RetryReader) Close() error {
+	if closer, ok := rr.reader.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type flakyReader struct {
+	reader io.Reader
+	errs   []error
+}
+
+func (fr *flakyReader) Read(p []byte) (int, error) {
+	if len(fr.errs) != 0 {
+		err := fr.errs[0]
+		fr.errs = fr.errs[1:]
+		return 0, err
+	}
+	return fr.reader.Read(p)
+}
+
+func (fr *flakyReader) Seek(offset int64, whence int) (int64, error) {
+	return fr.reader.(io.Seeker).Seek(offset, whence)
+}
+
+func TestRetryReader(t *testing.T) {
+	t.Run("ok, transient errors are retried", func(t *testing.T) {
+		retry := newRetryReader(&flakyReader{
+			reader: strings.NewReader("data"),
+			errs:   []error{syscall.EIO, &os.PathError{Op: "read", Path: "from", Err: syscall.EIO}},
+		}, "", 2, 0)
+
+		data, err := io.ReadAll(retry)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "data", string(data))
+		assert.Equal(t, 2, retry.count)
+	})
+
+	t.Run("error, retries are exhausted", func(t *testing.T) {
+		retry := newRetryReader(&flakyReader{
+			reader: strings.NewReader("data"),
+			errs:   []error{syscall.EIO, syscall.EIO, syscall.EIO},
+		}, "", 2, 0)
+
+		_, err := io.ReadAll(retry)
+
+		assert.ErrorIs(t, err, syscall.EIO)
+		assert.Equal(t, 2, retry.count)
+	})
+
+	t.Run("error, permission denied is not retried", func(t *testing.T) {
+		retry := newRetryReader(&flakyReader{
+			reader: strings.NewReader("data"),
+			errs:   []error{os.ErrPermission},
+		}, "", 2, 0)
+
+		_, err := io.ReadAll(retry)
+
+		assert.ErrorIs(t, err, os.ErrPermission)
+		assert.Equal(t, 0, retry.count)
+	})
+
+	t.Run("ok, source is reopened at the offset reached", func(t *testing.T) {
+		path := t.TempDir() + "/from"
+		assert.NoError(t, os.WriteFile(path, []byte("0123456789"), 0o644))
+		file, err := os.Open(path)
+		assert.NoError(t, err)
+		_, err = file.Seek(2, io.SeekStart)
+		assert.NoError(t, err)
+		flaky := &flakyReader{reader: file}
+		retry := newRetryReader(flaky, path, 1, 0)
+
+		head := make([]byte, 3)
+		_, err = io.ReadFull(retry, head)
+		assert.NoError(t, err)
+		flaky.errs = []error{syscall.EIO}
+		rest, err := io.ReadAll(retry)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "23456789", string(head)+string(rest))
+		assert.Equal(t, 1, retry.count)
+		assert.NoError(t, retry.Close())
+		assert.NoError(t, file.Close())
+	})
+}
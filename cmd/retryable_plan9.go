@@ -0,0 +1,10 @@
+package main
+
+import (
+	"errors"
+	"syscall"
+)
+
+func isRetryable(err error) bool {
+	return errors.Is(err, syscall.EIO)
+}
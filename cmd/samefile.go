@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// checkSameFile refuses to write into one of the inputs. os.Stat follows
+// symlinks and os.SameFile compares devices and inodes, so links to -from
+// are caught too.
+func checkSameFile(opts *Options) error {
+	for _, to := range append([]string{opts.To}, opts.Tee...) {
+		if to == "" {
+			continue
+		}
+		output, err := os.Stat(to)
+		if err != nil {
+			continue
+		}
+		for _, from := range opts.From {
+			if isInfiniteSource(from) {
+				continue
+			}
+			input, err := os.Stat(from)
+			if err == nil && os.SameFile(input, output) {
+				return fmt.Errorf("input and output are the same file: -from %s, -to %s", from, to)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var sizeSuffixes = []struct {
+	suffix     string
+	multiplier uint64
+}{
+	{"kB", 1000},
+	{"MB", 1000 * 1000},
+	{"GB", 1000 * 1000 * 1000},
+	{"TB", 1000 * 1000 * 1000 * 1000},
+	{"K", 1 << 10},
+	{"M", 1 << 20},
+	{"G", 1 << 30},
+	{"T", 1 << 40},
+}
+
+var errInvalidSize = errors.New("invalid size")
+
+func parseSize(value string) (uint64, error) {
+	number, multiplier := value, uint64(1)
+	for _, s := range sizeSuffixes {
+		if strings.HasSuffix(value, s.suffix) {
+			number, multiplier = strings.TrimSuffix(value, s.suffix), s.multiplier
+			break
+		}
+	}
+
+	amount, ok := new(big.Rat).SetString(number)
+	if !ok || strings.ContainsAny(number, "/eE") || amount.Sign() < 0 {
+		return 0, fmt.Errorf("%w %q: expected a number with an optional K, M, G, T, kB, MB, GB or TB suffix", errInvalidSize, value)
+	}
+	amount.Mul(amount, new(big.Rat).SetUint64(multiplier))
+	if !amount.IsInt() {
+		return 0, fmt.Errorf("%w %q: not a whole number of bytes", errInvalidSize, value)
+	}
+	if !amount.Num().IsUint64() {
+		return 0, fmt.Errorf("%w %q: overflows 64 bits", errInvalidSize, value)
+	}
+	return amount.Num().Uint64(), nil
+}
+
+type sizeValue uint64
+
+func (s *sizeValue) String() string {
+	return strconv.FormatUint(uint64(*s), 10)
+}
+
+func (s *sizeValue) Set(value string) error {
+	size, err := parseSize(value)
+	if err != nil {
+		return err
+	}
+	*s = sizeValue(size)
+	return nil
+}
+
+type signedSizeValue int64
+
+func (s *signedSizeValue) String() string {
+	return strconv.FormatInt(int64(*s), 10)
+}
+
+func (s *signedSizeValue) Set(value string) error {
+	magnitude, negative := strings.CutPrefix(value, "-")
+	size, err := parseSize(magnitude)
+	if err != nil {
+		return err
+	}
+	if size > math.MaxInt64 {
+		return fmt.Errorf("%w %q: overflows 63 bits", errInvalidSize, value)
+	}
+	*s = signedSizeValue(size)
+	if negative {
+		*s = -*s
+	}
+	return nil
+}
+
+func formatSize(size int64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}
+	value, unit := float64(size), 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSize(t *testing.T) {
+	for _, tc := range []struct {
+		value    string
+		expected uint64
+	}{
+		{"0", 0},
+		{"0K", 0},
+		{"512", 512},
+		{"4K", 4 << 10},
+		{"1536M", 1536 << 20},
+		{"1.5G", 3 << 29},
+		{"2T", 2 << 40},
+		{"1kB", 1000},
+		{"2.5MB", 2500000},
+		{"3GB", 3000000000},
+		{"18446744073709551615", 18446744073709551615},
+	} {
+		size, err := parseSize(tc.value)
+
+		assert.NoError(t, err, tc.value)
+		assert.Equal(t, tc.expected, size, tc.value)
+	}
+
+	for _, tc := range []struct {
+		value string
+		err   string
+	}{
+		{"", `invalid size "": expected a number`},
+		{"1X", `invalid size "1X": expected a number`},
+		{"K", `invalid size "K": expected a number`},
+		{"1KB", `invalid size "1KB": expected a number`},
+		{"-1", `invalid size "-1": expected a number`},
+		{"1/2K", `invalid size "1/2K": expected a number`},
+		{"1e3", `invalid size "1e3": expected a number`},
+		{"1.5", `invalid size "1.5": not a whole number of bytes`},
+		{"18446744073709551616", `invalid size "18446744073709551616": overflows 64 bits`},
+		{"16777216T", `invalid size "16777216T": overflows 64 bits`},
+	} {
+		_, err := parseSize(tc.value)
+
+		assert.ErrorIs(t, err, errInvalidSize, tc.value)
+		assert.ErrorContains(t, err, tc.err, tc.value)
+	}
+}
+
+func TestSignedSizeValue(t *testing.T) {
+	var size signedSizeValue
+
+	assert.NoError(t, size.Set("-4K"))
+	assert.Equal(t, signedSizeValue(-4096), size)
+	assert.NoError(t, size.Set("1MB"))
+	assert.Equal(t, "1000000", size.String())
+	assert.ErrorContains(t, size.Set("-8E"), `invalid size "8E"`)
+	assert.ErrorContains(t, size.Set("9223372036854775808"), "overflows 63 bits")
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"io"
+	"os"
+)
+
+type sparseWriter struct {
+	file  *os.File
+	holes int64
+}
+
+func newSparseWriter(writer io.WriteCloser) io.WriteCloser {
+	file, ok := writer.(*os.File)
+	if !ok {
+		diag.verbosef("-sparse ignored, the output is not a file")
+		return writer
+	}
+	if stat, err := file.Stat(); err != nil || !stat.Mode().IsRegular() {
+		diag.verbosef("-sparse ignored, %s is not a regular file", file.Name())
+		return writer
+	}
+	return &sparseWriter{file: file}
+}
+
+func isZero(p []byte) bool {
+	for _, b := range p {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (sw *sparseWriter) Write(p []byte) (int, error) {
+	if !isZero(p) {
+		return sw.file.Write(p)
+	}
+	if _, err := sw.file.Seek(int64(len(p)), io.SeekCurrent); err != nil {
+		return 0, err
+	}
+	sw.holes += int64(len(p))
+	return len(p), nil
+}
+
+func (sw *sparseWriter) truncate() error {
+	end, err := sw.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	return sw.file.Truncate(end)
+}
+
+func (sw *sparseWriter) Name() string {
+	return sw.file.Name()
+}
+
+func (sw *sparseWriter) Sync() error {
+	if err := sw.truncate(); err != nil {
+		return err
+	}
+	return sw.file.Sync()
+}
+
+func (sw *sparseWriter) Close() error {
+	if err := sw.truncate(); err != nil {
+		_ = sw.file.Close()
+		return err
+	}
+	return sw.file.Close()
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+)
+
+func (bc *blockCopier) printStatus(output io.Writer) {
+	elapsed := time.Since(bc.started)
+	written := bc.written.Load()
+	_, _ = fmt.Fprintf(output, "%d bytes read, %d bytes written, %s, %s/s\n",
+		bc.source.read.Load(), written, elapsed.Round(time.Millisecond), formatSize(int64(float64(written)/elapsed.Seconds())))
+}
+
+func watchStatusSignals(copier *blockCopier, output io.Writer) (stop func()) {
+	if len(statusSignals) == 0 {
+		return func() {}
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, statusSignals...)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-signals:
+				copier.printStatus(output)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
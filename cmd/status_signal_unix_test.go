@@ -0,0 +1,55 @@
+//go:build unix
+
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusSignal(t *testing.T) {
+	binPath := composeBinaryPath()
+	cmd := exec.Command("go", "build", "-o", binPath, "./")
+	assert.NoError(t, cmd.Run())
+	defer func() {
+		assert.NoError(t, os.Remove(binPath))
+	}()
+
+	t.Run("ok, SIGUSR1 prints statistics without interrupting the copy", func(t *testing.T) {
+		cmd = exec.Command(binPath, "-quiet", "-block-size", "4")
+		stdin, err := cmd.StdinPipe()
+		assert.NoError(t, err)
+		stdout, err := cmd.StdoutPipe()
+		assert.NoError(t, err)
+		stderr, err := cmd.StderrPipe()
+		assert.NoError(t, err)
+		assert.NoError(t, cmd.Start())
+
+		_, err = stdin.Write([]byte("01234567"))
+		assert.NoError(t, err)
+		copied := make([]byte, 8)
+		_, err = io.ReadFull(stdout, copied)
+		assert.NoError(t, err)
+		assert.NoError(t, cmd.Process.Signal(syscall.SIGUSR1))
+		status, err := bufio.NewReader(stderr).ReadString('\n')
+		assert.NoError(t, err)
+		_, err = stdin.Write([]byte("89"))
+		assert.NoError(t, err)
+		assert.NoError(t, stdin.Close())
+		rest, err := io.ReadAll(stdout)
+		assert.NoError(t, err)
+
+		err = cmd.Wait()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "0123456789", string(copied)+string(rest))
+		assert.True(t, strings.HasPrefix(status, "8 bytes read, 8 bytes written, "), status)
+	})
+}
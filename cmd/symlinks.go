@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+var symlinkPolicies = []string{"follow", "skip", "copy"}
+
+func isSymlink(path string) bool {
+	stat, err := os.Lstat(path)
+	return err == nil && stat.Mode()&fs.ModeSymlink != 0
+}
+
+func withoutSymlinks(paths []string) []string {
+	kept := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if isSymlink(path) {
+			diag.infof("warning: skipping symlink %s", path)
+			continue
+		}
+		kept = append(kept, path)
+	}
+	return kept
+}
+
+func copySymlink(from, to string, force bool) error {
+	target, err := os.Readlink(from)
+	if err != nil {
+		return err
+	}
+	if _, err = os.Lstat(to); err == nil {
+		if !force {
+			return fmt.Errorf("%w: %s, use -force to overwrite it", ErrDestinationExists, to)
+		}
+		if err = os.Remove(to); err != nil {
+			return err
+		}
+	}
+
+	if err = os.Symlink(target, to); err != nil {
+		return err
+	}
+	diag.verbosef("created symlink %s -> %s", to, target)
+	return nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+type teeTarget struct {
+	name    string
+	writer  io.WriteCloser
+	written int64
+	failed  bool
+}
+
+type teeWriter struct {
+	targets    []*teeTarget
+	bestEffort bool
+}
+
+func destinationName(path string) string {
+	if path == "" {
+		return "stdout"
+	}
+	return path
+}
+
+func createTeeWriter(opts *Options) (io.WriteCloser, error) {
+	tee := &teeWriter{bestEffort: opts.TeeBestEffort}
+	for _, path := range append([]string{opts.To}, opts.Tee...) {
+		target := *opts
+		target.To = path
+		writer, err := createWriter(&target)
+		if err != nil {
+			tee.abort()
+			return nil, fmt.Errorf("%s: %w", destinationName(path), err)
+		}
+		tee.targets = append(tee.targets, &teeTarget{name: destinationName(path), writer: writer})
+	}
+	return tee, nil
+}
+
+func (tw *teeWriter) Write(p []byte) (int, error) {
+	alive := 0
+	for _, target := range tw.targets {
+		if target.failed {
+			continue
+		}
+		n, err := target.writer.Write(p)
+		target.written += int64(n)
+		if err == nil && n < len(p) {
+			err = io.ErrShortWrite
+		}
+		if err != nil {
+			target.failed = true
+			err = fmt.Errorf("destination %s failed after %d bytes: %w", target.name, target.written, err)
+			if !tw.bestEffort {
+				return 0, err
+			}
+			diag.errorf("%v, continuing with the other destinations", err)
+			abortWriter(target.writer)
+			continue
+		}
+		alive++
+	}
+	if alive == 0 {
+		return 0, fmt.Errorf("all destinations failed")
+	}
+	return len(p), nil
+}
+
+func (tw *teeWriter) Close() error {
+	var errs []error
+	for _, target := range tw.targets {
+		if target.failed {
+			continue
+		}
+		if err := target.writer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", target.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (tw *teeWriter) abort() {
+	for _, target := range tw.targets {
+		abortWriter(target.writer)
+	}
+}
+
+func (tw *teeWriter) summary() string {
+	var summary string
+	for _, target := range tw.targets {
+		summary += fmt.Sprintf("\n%d bytes written to %s", target.written, target.name)
+		if target.failed {
+			summary += " (failed)"
+		}
+	}
+	return summary
+}
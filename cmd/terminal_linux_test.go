@@ -0,0 +1,130 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerminalStdin(t *testing.T) {
+	binPath := composeBinaryPath()
+	cmd := exec.Command("go", "build", "-o", binPath, "./")
+	assert.NoError(t, cmd.Run())
+	defer func() {
+		assert.NoError(t, os.Remove(binPath))
+	}()
+
+	t.Run("ok, a terminal stdin is announced and refused with -no-stdin", func(t *testing.T) {
+		_, terminal := openTerminal(t)
+
+		cmd = exec.Command(binPath, "-no-stdin")
+		cmd.Stdin = terminal
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.Error(t, cmd.Run())
+		assert.Contains(t, stderr.String(), "stdin is a terminal, pass -from or pipe the input")
+
+		cmd = exec.Command(binPath, "-timeout", "100ms")
+		cmd.Stdin = terminal
+		stderr.Reset()
+		cmd.Stderr = stderr
+
+		assert.Error(t, cmd.Run())
+		assert.True(t, strings.HasPrefix(stderr.String(), "reading from terminal; press Ctrl-D to end input or pass -from\n"), stderr.String())
+
+		cmd = exec.Command(binPath, "-no-stdin")
+		cmd.Stdin = strings.NewReader("abc")
+		stdout := &strings.Builder{}
+		cmd.Stdout = stdout
+		stderr.Reset()
+		cmd.Stderr = stderr
+
+		assert.NoError(t, cmd.Run())
+		assert.Equal(t, "abc", stdout.String())
+		assert.NotContains(t, stderr.String(), "terminal")
+	})
+
+	t.Run("ok, -interactive asks on the controlling terminal", func(t *testing.T) {
+		outputFile := filepath.Join(t.TempDir(), "out")
+		for _, tc := range []struct {
+			answer   string
+			expected string
+		}{
+			{"n\n", "old"},
+			{"y\n", "new"},
+		} {
+			assert.NoError(t, os.WriteFile(outputFile, []byte("old"), 0o600))
+			master, terminal := openTerminal(t)
+			_, err := master.Write([]byte(tc.answer))
+			assert.NoError(t, err)
+
+			cmd = exec.Command(binPath, "-quiet", "-interactive", "-to", outputFile)
+			cmd.Stdin = strings.NewReader("new")
+			cmd.ExtraFiles = []*os.File{terminal}
+			cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true, Ctty: 3}
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			err = cmd.Run()
+
+			assert.Equal(t, tc.expected == "new", err == nil, stderr.String())
+			assert.Contains(t, stderr.String(), "overwrite '"+outputFile+"'? [y/N] ")
+			data, err := os.ReadFile(outputFile)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, string(data))
+		}
+	})
+
+	t.Run("error, -interactive without a terminal refuses unless -force", func(t *testing.T) {
+		outputFile := filepath.Join(t.TempDir(), "out")
+		assert.NoError(t, os.WriteFile(outputFile, []byte("old"), 0o600))
+
+		cmd = exec.Command(binPath, "-interactive", "-to", outputFile)
+		cmd.Stdin = strings.NewReader("new")
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+		stderr := &strings.Builder{}
+		cmd.Stderr = stderr
+
+		assert.Error(t, cmd.Run())
+		assert.Contains(t, stderr.String(), "can not ask to overwrite it without a terminal, use -force")
+
+		cmd = exec.Command(binPath, "-quiet", "-interactive", "-force", "-to", outputFile)
+		cmd.Stdin = strings.NewReader("new")
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+		assert.NoError(t, cmd.Run())
+		data, err := os.ReadFile(outputFile)
+		assert.NoError(t, err)
+		assert.Equal(t, "new", string(data))
+	})
+}
+
+func openTerminal(t *testing.T) (master, terminal *os.File) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("no pseudo terminals: %v", err)
+	}
+	t.Cleanup(func() { _ = master.Close() })
+
+	var unlock, number uint32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); errno != 0 {
+		t.Skipf("can not unlock pseudo terminal: %v", errno)
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&number))); errno != 0 {
+		t.Skipf("can not get pseudo terminal number: %v", errno)
+	}
+	terminal, err = os.OpenFile("/dev/pts/"+strconv.Itoa(int(number)), os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("can not open pseudo terminal: %v", err)
+	}
+	t.Cleanup(func() { _ = terminal.Close() })
+	return master, terminal
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+func scanRunes(buffer []byte, atEOF bool, fn func(r rune, raw []byte)) int {
+	i := 0
+	for i < len(buffer) {
+		if !atEOF && !utf8.FullRune(buffer[i:]) {
+			break
+		}
+		r, runeSize := utf8.DecodeRune(buffer[i:])
+		fn(r, buffer[i:i+runeSize])
+		i += runeSize
+	}
+	return i
+}
+
+type SqueezeReader struct {
+	reader   io.Reader
+	buffer   []byte
+	squeezed []byte
+	inSpaces bool
+}
+
+func (sr *SqueezeReader) Read(p []byte) (n int, err error) {
+	if len(sr.squeezed) != 0 {
+		sr.squeezed, n = copyFromChecked(p, sr.squeezed)
+		return n, nil
+	}
+
+	buffer := make([]byte, len(p))
+	n, err = sr.reader.Read(buffer)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+	sr.buffer = append(sr.buffer, buffer[:n]...)
+
+	atEOF := errors.Is(err, io.EOF)
+	consumed := scanRunes(sr.buffer, atEOF, func(r rune, raw []byte) {
+		if !unicode.IsSpace(r) {
+			sr.squeezed = append(sr.squeezed, raw...)
+			sr.inSpaces = false
+			return
+		}
+		if !sr.inSpaces {
+			sr.squeezed = append(sr.squeezed, ' ')
+		}
+		sr.inSpaces = true
+	})
+	sr.buffer = sr.buffer[consumed:]
+
+	if atEOF && len(sr.squeezed) == 0 {
+		return 0, io.EOF
+	}
+	return sr.Read(p)
+}
+
+type NormReader struct {
+	reader     io.Reader
+	form       norm.Form
+	buffer     []byte
+	normalized []byte
+}
+
+func (nr *NormReader) Read(p []byte) (n int, err error) {
+	if len(nr.normalized) != 0 {
+		nr.normalized, n = copyFromChecked(p, nr.normalized)
+		return n, nil
+	}
+
+	buffer := make([]byte, len(p))
+	n, err = nr.reader.Read(buffer)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+	nr.buffer = append(nr.buffer, buffer[:n]...)
+
+	stable := len(nr.buffer)
+	if !errors.Is(err, io.EOF) {
+		stable = max(nr.form.LastBoundary(nr.buffer), 0)
+	}
+	nr.normalized = nr.form.Append(nr.normalized, nr.buffer[:stable]...)
+	nr.buffer = nr.buffer[stable:]
+
+	if errors.Is(err, io.EOF) && len(nr.normalized) == 0 {
+		return 0, io.EOF
+	}
+	return nr.Read(p)
+}
+
+var asciiFoldTable = map[rune]string{
+	'ß': "ss", 'ẞ': "SS",
+	'æ': "ae", 'Æ': "AE",
+	'œ': "oe", 'Œ': "OE",
+	'ø': "o", 'Ø': "O",
+	'đ': "d", 'Đ': "D",
+	'ð': "d", 'Ð': "D",
+	'ł': "l", 'Ł': "L",
+	'þ': "th", 'Þ': "TH",
+	'ı': "i",
+}
+
+func isCombiningDiacritic(r rune) bool {
+	return r >= 0x0300 && r <= 0x036f
+}
+
+func foldRune(r rune) (string, bool) {
+	if folded, ok := asciiFoldTable[r]; ok {
+		return folded, true
+	}
+
+	folded := make([]rune, 0, 1)
+	for _, d := range norm.NFD.String(string(r)) {
+		if isCombiningDiacritic(d) {
+			continue
+		}
+		if d >= utf8.RuneSelf {
+			return "", false
+		}
+		folded = append(folded, d)
+	}
+	return string(folded), len(folded) != 0
+}
+
+type FoldReader struct {
+	reader  io.Reader
+	replace bool
+	buffer  []byte
+	folded  []byte
+}
+
+func (fr *FoldReader) Read(p []byte) (n int, err error) {
+	if len(fr.folded) != 0 {
+		fr.folded, n = copyFromChecked(p, fr.folded)
+		return n, nil
+	}
+
+	buffer := make([]byte, len(p))
+	n, err = fr.reader.Read(buffer)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+	fr.buffer = append(fr.buffer, buffer[:n]...)
+
+	atEOF := errors.Is(err, io.EOF)
+	consumed := scanRunes(fr.buffer, atEOF, func(r rune, raw []byte) {
+		if r < utf8.RuneSelf {
+			fr.folded = append(fr.folded, raw...)
+			return
+		}
+		if isCombiningDiacritic(r) {
+			return
+		}
+
+		switch folded, ok := foldRune(r); {
+		case ok:
+			fr.folded = append(fr.folded, folded...)
+		case fr.replace:
+			fr.folded = append(fr.folded, '?')
+		default:
+			fr.folded = append(fr.folded, raw...)
+		}
+	})
+	fr.buffer = fr.buffer[consumed:]
+
+	if atEOF && len(fr.folded) == 0 {
+		return 0, io.EOF
+	}
+	return fr.Read(p)
+}
+
+var (
+	utf8BOM    = []byte{0xef, 0xbb, 0xbf}
+	utf16LEBOM = []byte{0xff, 0xfe}
+	utf16BEBOM = []byte{0xfe, 0xff}
+)
+
+type StripBOMReader struct {
+	reader  io.Reader
+	head    []byte
+	checked bool
+}
+
+func (sr *StripBOMReader) Read(p []byte) (n int, err error) {
+	if sr.checked {
+		if len(sr.head) != 0 {
+			sr.head, n = copyFromChecked(p, sr.head)
+			return n, nil
+		}
+		return sr.reader.Read(p)
+	}
+
+	buffer := make([]byte, len(p))
+	n, err = sr.reader.Read(buffer)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+	sr.head = append(sr.head, buffer[:n]...)
+	if len(sr.head) < len(utf8BOM) && !errors.Is(err, io.EOF) {
+		return sr.Read(p)
+	}
+
+	sr.checked = true
+	switch {
+	case bytes.HasPrefix(sr.head, utf8BOM):
+		sr.head = sr.head[len(utf8BOM):]
+	case bytes.HasPrefix(sr.head, utf16LEBOM), bytes.HasPrefix(sr.head, utf16BEBOM):
+		return 0, fmt.Errorf("%w: input starts with a UTF-16 byte order mark, convert it to UTF-8 first", ErrInvalidData)
+	}
+	if errors.Is(err, io.EOF) && len(sr.head) == 0 {
+		return 0, io.EOF
+	}
+	return sr.Read(p)
+}
+
+type AddBOMReader struct {
+	reader io.Reader
+	bom    []byte
+	added  bool
+}
+
+func (ar *AddBOMReader) Read(p []byte) (n int, err error) {
+	if !ar.added {
+		ar.added = true
+		ar.bom = utf8BOM
+	}
+	if len(ar.bom) != 0 {
+		ar.bom, n = copyFromChecked(p, ar.bom)
+		return n, nil
+	}
+	return ar.reader.Read(p)
+}
+
+type StripControlReader struct {
+	reader   io.Reader
+	buffer   []byte
+	stripped []byte
+}
+
+func (sr *StripControlReader) Read(p []byte) (n int, err error) {
+	if len(sr.stripped) != 0 {
+		sr.stripped, n = copyFromChecked(p, sr.stripped)
+		return n, nil
+	}
+
+	buffer := make([]byte, len(p))
+	n, err = sr.reader.Read(buffer)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+	sr.buffer = append(sr.buffer, buffer[:n]...)
+
+	atEOF := errors.Is(err, io.EOF)
+	consumed := scanRunes(sr.buffer, atEOF, func(r rune, raw []byte) {
+		if r == utf8.RuneError && len(raw) == 1 {
+			return
+		}
+		if unicode.Is(unicode.Cc, r) && r != '\t' && r != '\n' && r != '\r' {
+			return
+		}
+		sr.stripped = append(sr.stripped, raw...)
+	})
+	sr.buffer = sr.buffer[consumed:]
+
+	if atEOF && len(sr.stripped) == 0 {
+		return 0, io.EOF
+	}
+	return sr.Read(p)
+}
+
+type FixUTF8Reader struct {
+	reader io.Reader
+	buffer []byte
+	fixed  []byte
+}
+
+func (fr *FixUTF8Reader) Read(p []byte) (n int, err error) {
+	if len(fr.fixed) != 0 {
+		fr.fixed, n = copyFromChecked(p, fr.fixed)
+		return n, nil
+	}
+
+	buffer := make([]byte, len(p))
+	n, err = fr.reader.Read(buffer)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+	fr.buffer = append(fr.buffer, buffer[:n]...)
+
+	atEOF := errors.Is(err, io.EOF)
+	consumed := scanRunes(fr.buffer, atEOF, func(r rune, raw []byte) {
+		if r == utf8.RuneError && len(raw) == 1 {
+			fr.fixed = utf8.AppendRune(fr.fixed, utf8.RuneError)
+			return
+		}
+		fr.fixed = append(fr.fixed, raw...)
+	})
+	fr.buffer = fr.buffer[consumed:]
+
+	if atEOF && len(fr.fixed) == 0 {
+		return 0, io.EOF
+	}
+	return fr.Read(p)
+}
+
+func parseRuneMap(spec string) (map[rune]rune, error) {
+	from, to, ok := strings.Cut(spec, ":")
+	if !ok {
+		return nil, fmt.Errorf("%w: -map must look like FROM:TO, got %q", ErrInvalidConv, spec)
+	}
+	fromRunes, toRunes := []rune(from), []rune(to)
+	if len(fromRunes) != len(toRunes) {
+		return nil, fmt.Errorf("%w: -map sets must have equal length, got %d and %d", ErrInvalidConv, len(fromRunes), len(toRunes))
+	}
+
+	runeMap := make(map[rune]rune, len(fromRunes))
+	for i, r := range fromRunes {
+		runeMap[r] = toRunes[i]
+	}
+	return runeMap, nil
+}
+
+type RuneMapReader struct {
+	reader io.Reader
+	table  map[rune]rune
+	buffer []byte
+	mapped []byte
+}
+
+func (mr *RuneMapReader) Read(p []byte) (n int, err error) {
+	if len(mr.mapped) != 0 {
+		mr.mapped, n = copyFromChecked(p, mr.mapped)
+		return n, nil
+	}
+
+	buffer := make([]byte, len(p))
+	n, err = mr.reader.Read(buffer)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+	mr.buffer = append(mr.buffer, buffer[:n]...)
+
+	atEOF := errors.Is(err, io.EOF)
+	consumed := scanRunes(mr.buffer, atEOF, func(r rune, raw []byte) {
+		if mapped, ok := mr.table[r]; ok && (r != utf8.RuneError || len(raw) > 1) {
+			mr.mapped = utf8.AppendRune(mr.mapped, mapped)
+			return
+		}
+		mr.mapped = append(mr.mapped, raw...)
+	})
+	mr.buffer = mr.buffer[consumed:]
+
+	if atEOF && len(mr.mapped) == 0 {
+		return 0, io.EOF
+	}
+	return mr.Read(p)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+var ErrTimeout = fmt.Errorf("timed out")
+
+const abandonDelay = 100 * time.Millisecond
+
+// copyUntil runs copy, giving up at the deadline. The interrupted files get
+// expired deadlines so that pending reads and writes return; a copy stuck in
+// a file without deadline support is abandoned and finished reports false.
+// On SIGINT or SIGTERM the copy stops at the next block boundary instead.
+func (bc *blockCopier) copyUntil(deadline time.Time, files []*os.File) (finished bool, err error) {
+	done := make(chan error, 1)
+	go func() {
+		done <- bc.copy()
+	}()
+
+	var expired <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case err = <-done:
+		return true, err
+	case <-interrupted:
+		return bc.stopAtBlock(done, files)
+	case <-expired:
+	}
+
+	for _, file := range files {
+		_ = file.SetDeadline(time.Now())
+	}
+	select {
+	case <-done:
+		return true, ErrTimeout
+	case <-time.After(abandonDelay):
+		return false, ErrTimeout
+	}
+}
+
+// stopAtBlock waits for an interrupted copy, cutting pending reads short.
+// Only a copy stuck in a read is abandoned, never one in the middle of a write.
+func (bc *blockCopier) stopAtBlock(done <-chan error, files []*os.File) (finished bool, err error) {
+	for _, file := range files {
+		_ = file.SetReadDeadline(time.Now())
+	}
+	for {
+		select {
+		case err = <-done:
+			return true, err
+		case <-time.After(abandonDelay):
+			if bc.reading.Load() {
+				return false, ErrInterrupted
+			}
+		}
+	}
+}
+
+func interruptibleFiles(opts *Options) []*os.File {
+	var files []*os.File
+	if file, ok := opts.input.(*os.File); ok {
+		files = append(files, file)
+	}
+	if len(opts.From) == 0 {
+		files = append(files, os.Stdin)
+	}
+	if opts.To == "" && len(opts.Tee) == 0 {
+		files = append(files, os.Stdout)
+	}
+	return files
+}
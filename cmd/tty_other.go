@@ -0,0 +1,11 @@
+//go:build !(linux || windows || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package main
+
+import "os"
+
+const controllingTerminal = ""
+
+func isTerminal(*os.File) bool {
+	return false
+}
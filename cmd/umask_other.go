@@ -0,0 +1,9 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+func createMode() os.FileMode {
+	return 0o666
+}
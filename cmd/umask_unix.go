@@ -0,0 +1,14 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+func createMode() os.FileMode {
+	umask := syscall.Umask(0)
+	syscall.Umask(umask)
+	return 0o666 &^ os.FileMode(umask)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var ErrVerification = fmt.Errorf("verification failed")
+
+func verifyStart(opts *Options) int64 {
+	switch {
+	case opts.Append:
+		if stat, err := os.Stat(opts.To); err == nil {
+			return stat.Size()
+		}
+		return 0
+	case opts.Seek != 0:
+		return int64(opts.Seek)
+	case opts.SeekBlocks != 0:
+		return int64(opts.SeekBlocks * opts.WriteBlockSize)
+	}
+	return 0
+}
+
+func openSection(path string, offset int64) (*os.File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+func verifyDestination(opts *Options, copier *blockCopier, start int64) error {
+	file, err := openSection(opts.To, start)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	written := copier.written.Load()
+	hash := sha256.New()
+	read, err := io.CopyN(hash, file, written)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	if read != written || !bytes.Equal(hash.Sum(nil), copier.verify.Sum(nil)) {
+		return fmt.Errorf("%w: wrote %d bytes with sha256 %x to %s, read back %d bytes with sha256 %x",
+			ErrVerification, written, copier.verify.Sum(nil), opts.To, read, hash.Sum(nil))
+	}
+	diag.verbosef("verified %d bytes of %s by sha256", written, opts.To)
+
+	if len(opts.Conv) != 0 || len(opts.From) != 1 || isInfiniteSource(opts.From[0]) {
+		return nil
+	}
+	if stat, err := os.Stat(opts.From[0]); err != nil || !stat.Mode().IsRegular() {
+		return nil
+	}
+	return compareWithSource(opts, start, copier.written.Load())
+}
+
+func compareWithSource(opts *Options, start, length int64) error {
+	source, err := openSection(opts.From[0], opts.Offset)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	destination, err := openSection(opts.To, start)
+	if err != nil {
+		return err
+	}
+	defer destination.Close()
+
+	sourceReader, destinationReader := bufio.NewReader(source), bufio.NewReader(destination)
+	for offset := int64(0); offset < length; offset++ {
+		expected, err := sourceReader.ReadByte()
+		if err != nil {
+			return err
+		}
+		actual, err := destinationReader.ReadByte()
+		if err != nil {
+			return fmt.Errorf("%w: %s ends at byte %d of %d copied", ErrVerification, opts.To, offset, length)
+		}
+		if expected != actual {
+			return fmt.Errorf("%w: %s differs from %s at byte %d of the copied range", ErrVerification, opts.To, opts.From[0], offset)
+		}
+	}
+	diag.verbosef("compared %d bytes of %s with %s", length, opts.To, opts.From[0])
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+)
+
+// Overridden at build time with -ldflags "-X main.version=v1.2.3 -X main.commit=abc123".
+var (
+	version string
+	commit  string
+)
+
+type buildVersion struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	Go      string `json:"go"`
+}
+
+func readBuildVersion() buildVersion {
+	result := buildVersion{Version: "(devel)", Commit: "unknown", Go: runtime.Version()}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if info.Main.Version != "" {
+			result.Version = info.Main.Version
+		}
+		modified := false
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				result.Commit = setting.Value
+			case "vcs.modified":
+				modified = setting.Value == "true"
+			}
+		}
+		if modified && result.Commit != "unknown" {
+			result.Commit += "-dirty"
+		}
+	}
+
+	if version != "" {
+		result.Version = version
+	}
+	if commit != "" {
+		result.Commit = commit
+	}
+	return result
+}
+
+func printVersion(output io.Writer, asJSON bool) error {
+	build := readBuildVersion()
+	if asJSON {
+		return json.NewEncoder(output).Encode(build)
+	}
+	_, err := fmt.Fprintf(output, "copying-files-utility %s\ncommit: %s\ngo: %s\n", build.Version, build.Commit, build.Go)
+	return err
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+)
+
+// writeFailure names the destination and the bytes that reached it, adding
+// the free space of its filesystem when the disk is full. Several -to are
+// already named by the tee writer.
+func writeFailure(opts *Options, written int64, err error) error {
+	path := opts.To
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) && (opts.To != "" || len(opts.Tee) != 0) {
+		path = pathErr.Path
+	}
+
+	hint := ""
+	if isNoSpace(err) && path != "" {
+		if free, statErr := freeSpace(filepath.Dir(path)); statErr == nil {
+			hint = fmt.Sprintf(", the filesystem of %s reports %s free", filepath.Dir(path), formatSize(int64(free)))
+		}
+	}
+	if len(opts.Tee) != 0 {
+		return destinationError(fmt.Errorf("%w%s", err, hint))
+	}
+
+	if pathErr != nil {
+		err = pathErr.Err
+	}
+	if path == "" {
+		path = "stdout"
+	}
+	return destinationError(fmt.Errorf("can not write to %s after %d bytes written: %w%s", path, written, err, hint))
+}
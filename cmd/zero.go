@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+const zeroSource = "zero:"
+
+// ZeroReader is the endless stream of NUL bytes behind -from zero:, the
+// portable /dev/zero.
+type ZeroReader struct{}
+
+func (ZeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func isInfiniteSource(path string) bool {
+	return path == zeroSource
+}
+
+func checkInfiniteSource(opts *Options) error {
+	if !slices.ContainsFunc(opts.From, isInfiniteSource) {
+		return nil
+	}
+	if len(opts.From) != 1 {
+		return fmt.Errorf("%w: %s must be the only -from, use ./%s for a file with that name", ErrInvalidFlag, zeroSource, zeroSource)
+	}
+	if !isFlagSet("limit") && !isFlagSet("count") {
+		return fmt.Errorf("%w: %s never ends, bound it with -limit or -count", ErrInvalidFlag, zeroSource)
+	}
+	if opts.Offset < 0 || opts.Recursive || opts.Resume || opts.SkipIdentical != "" || opts.Direct || len(opts.Preserve) != 0 {
+		return fmt.Errorf("%w: %s cannot be used with negative -offset, -recursive, -resume, -skip-identical, -direct or -preserve", ErrInvalidFlag, zeroSource)
+	}
+	return nil
+}
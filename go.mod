@@ -2,10 +2,14 @@ module lecture03_homework
 
 go 1.22
 
-require github.com/stretchr/testify v1.9.0
+require (
+	github.com/klauspost/compress v1.17.11
+	github.com/stretchr/testify v1.9.0
+	golang.org/x/text v0.21.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )